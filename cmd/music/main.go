@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/commands/archive"
+	"github.com/ryo-kagawa/Music/commands/cdrip"
+	"github.com/ryo-kagawa/Music/commands/convertflac"
+	"github.com/ryo-kagawa/Music/commands/cueexport"
+	"github.com/ryo-kagawa/Music/commands/cueimport"
+	"github.com/ryo-kagawa/Music/commands/cuelint"
+	"github.com/ryo-kagawa/Music/commands/setconvert"
+	"github.com/ryo-kagawa/Music/commands/setsplit"
+	"github.com/ryo-kagawa/Music/commands/verifylog"
+	"github.com/ryo-kagawa/Music/commands/wavesplitcue"
+	"github.com/ryo-kagawa/Music/config"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/Music/utils"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(arguments []string) int {
+	// NOTE: サブコマンド名より前の「--」で始まる引数を共通の引数とする
+	globalCount := 0
+	for globalCount < len(arguments) && strings.HasPrefix(arguments[globalCount], "--") {
+		globalCount++
+	}
+	globalArguments, err := commandline.ArgumentsParse[GlobalArguments](arguments[:globalCount])
+	if err != nil {
+		return output("text", "", commands.UsageError(err.Error(), usage))
+	}
+	if globalArguments.Help {
+		return output(globalArguments.Format, usage, nil)
+	}
+	configPath := globalArguments.Config
+	if configPath == "" {
+		configPath, err = config.DefaultPath()
+		if err != nil {
+			return output(globalArguments.Format, "", err)
+		}
+	}
+	configuration, err := config.Load(configPath)
+	if err != nil {
+		return output(globalArguments.Format, "", err)
+	}
+	for _, role := range configuration.Roles {
+		if err := cue.RegisterRole(cue.Role{Key: role.Key, Tag: role.Tag, Instrument: role.Instrument}); err != nil {
+			return output(globalArguments.Format, "", fmt.Errorf("%s: %w", configPath, err))
+		}
+	}
+	for _, value := range globalArguments.InputEncoding {
+		filePath, encodingName := splitInputEncoding(value)
+		if err := utils.ForceEncoding(filePath, encodingName); err != nil {
+			return output(globalArguments.Format, "", err)
+		}
+	}
+	global := commands.Global{
+		Config:  configuration,
+		Quiet:   globalArguments.Quiet,
+		Verbose: globalArguments.Verbose,
+		Format:  globalArguments.Format,
+	}
+
+	result, err := commandline.Execute(
+		Command{},
+		arguments[globalCount:],
+		archive.Command{Global: global},
+		cdrip.Command{Global: global},
+		convertflac.Command{Global: global},
+		cueexport.Command{Global: global},
+		cueimport.Command{Global: global},
+		cuelint.Command{Global: global},
+		setconvert.Command{Global: global},
+		setsplit.Command{Global: global},
+		verifylog.Command{Global: global},
+		wavesplitcue.Command{Global: global},
+	)
+	return output(global.Format, result, err)
+}
+
+func output(format string, result string, err error) int {
+	exitCode := commands.ExitCode(err)
+	if format == "json" {
+		value := struct {
+			Result   string `json:"result"`
+			Error    string `json:"error,omitempty"`
+			ExitCode int    `json:"exitCode"`
+		}{
+			Result:   result,
+			ExitCode: exitCode,
+		}
+		if err != nil {
+			value.Error = err.Error()
+		}
+		binary, _ := json.MarshalIndent(value, "", "  ")
+		fmt.Fprintln(os.Stdout, string(binary))
+		return exitCode
+	}
+	if result != "" {
+		fmt.Fprint(os.Stdout, result)
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+	}
+	return exitCode
+}
+
+// サブコマンドが指定されなかった場合に使い方を表示する
+type Command struct{}
+
+var _ = (commandline.RootCommand)(Command{})
+
+func (Command) Execute(arguments []string) (string, error) {
+	if len(arguments) == 0 || arguments[0] == "help" {
+		return usage, nil
+	}
+	return "", commands.UsageError(fmt.Sprintf("unknown command: %s", arguments[0]), usage)
+}
+
+const usage = `usage: music [global options] <command> [arguments]
+
+commands:
+  archive         rip, split, encode, tag and verify a disc in one go
+  cd-rip          rip an audio CD to WAVE files
+  convert-flac    encode the WAVE files of a cue sheet to FLAC
+  cue-export      convert a cue sheet to JSON or YAML
+  cue-import      convert JSON or YAML back to a cue sheet
+  cue-lint        check a cue sheet against its audio and fix safe errors
+  set-convert     encode the split WAVE files of every disc of a set to FLAC
+  set-split       split every disc of a multi-disc set into Disc N folders
+  verify-log      verify audio files against an EAC/XLD/cd-rip log
+  wave-split-cue  split a WAVE image into tracks by its cue sheet
+
+global options:
+  --config=<path>  config file (default: config.json next to the executable)
+  --quiet          suppress progress output
+  --verbose        print detailed progress
+  --format=<fmt>   result format: text or json (default: text)
+  --input-encoding=[<file>=]<enc>
+                   read <file> (or every cue sheet and log without <file>) as
+                   utf-8, utf-16le, utf-16be, shift-jis, euc-jp or latin-1
+                   instead of detecting the encoding; can be repeated
+  --help           show this help
+`
+
+type GlobalArguments struct {
+	Config  string `key:"--config"`
+	Quiet   bool   `key:"--quiet"`
+	Verbose bool   `key:"--verbose"`
+	Format  string `key:"--format" default:"text"`
+	// [ファイル=]文字コード
+	InputEncoding []string `key:"--input-encoding"`
+	Help          bool     `key:"--help"`
+}
+
+func (g *GlobalArguments) Validate() error {
+	if g.Format != "text" && g.Format != "json" {
+		return fmt.Errorf("--format must be text or json (got %s)", g.Format)
+	}
+	if g.Quiet && g.Verbose {
+		return errors.New("--quiet and --verbose cannot be used together")
+	}
+	for _, value := range g.InputEncoding {
+		if _, encodingName := splitInputEncoding(value); !utils.IsEncoding(encodingName) {
+			return fmt.Errorf("--input-encoding must be utf-8, utf-16le, utf-16be, shift-jis, euc-jp or latin-1 (got %s)", encodingName)
+		}
+	}
+	return nil
+}
+
+// --input-encodingの値をファイルのパスと文字コードに分ける
+// NOTE: ファイル名に「=」を含む場合に備えて最後の「=」で分ける
+func splitInputEncoding(value string) (string, string) {
+	index := strings.LastIndex(value, "=")
+	if index < 0 {
+		return "", value
+	}
+	return value[:index], value[index+1:]
+}
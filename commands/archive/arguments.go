@@ -0,0 +1,104 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const usage = `usage: music archive [options]
+
+Rips a disc and runs the following stages in order:
+  rip     read the disc into image.wav and write the rip log
+  cue     create image.cue from the table of contents
+  split   split image.wav into tracks by image.cue
+  encode  encode the tracks to FLAC
+  tag     write tags from image.cue into the FLAC files
+  verify  verify the FLAC files against the rip log
+  report  write archive-report.txt
+
+Completed stages are recorded in archive.json in the output directory
+and are skipped when the command is run again.
+
+options:
+  --output=<directory>  output directory (default: .)
+  --title=<title>       album title written to the cue sheet (default: Untitled)
+  --performer=<name>    album performer written to the cue sheet
+  --from=<stage>        run again from the given stage
+  --stop-after=<stage>  stop after the given stage
+  --deemphasis          apply de-emphasis to tracks with the PRE flag when splitting
+  --drive=<drive>       passed to cd-rip
+  --verify=<count>      passed to cd-rip
+  --offset=<samples>    passed to cd-rip
+  --read-mode=<mode>    passed to cd-rip
+  --retries=<count>     passed to cd-rip
+  --retry-backoff=<ms>  passed to cd-rip
+  --retry-speed=<x>     passed to cd-rip
+  --on-error=<action>   passed to cd-rip
+  --help                show this help
+`
+
+type Arguments struct {
+	Help       bool   `key:"--help"`
+	Output     string `key:"--output" default:"."`
+	Title      string `key:"--title" default:"Untitled"`
+	Performer  string `key:"--performer"`
+	From       string `key:"--from"`
+	StopAfter  string `key:"--stop-after"`
+	Deemphasis bool   `key:"--deemphasis"`
+	// NOTE: cd-ripの引数は未指定の場合に設定ファイルの値を使用するため既定値を持たない
+	Drive        string `key:"--drive"`
+	Verify       string `key:"--verify"`
+	Offset       string `key:"--offset"`
+	ReadMode     string `key:"--read-mode"`
+	Retries      string `key:"--retries"`
+	RetryBackoff string `key:"--retry-backoff"`
+	RetrySpeed   string `key:"--retry-speed"`
+	OnError      string `key:"--on-error"`
+}
+
+func (a *Arguments) Validate() error {
+	if a.Help {
+		return nil
+	}
+	errs := []error{}
+	if a.From != "" && !slices.Contains(stages, a.From) {
+		errs = append(errs, fmt.Errorf("--from must be one of %v (got %s)", stages, a.From))
+	}
+	if a.StopAfter != "" && !slices.Contains(stages, a.StopAfter) {
+		errs = append(errs, fmt.Errorf("--stop-after must be one of %v (got %s)", stages, a.StopAfter))
+	}
+	if a.From != "" && a.StopAfter != "" && slices.Index(stages, a.StopAfter) < slices.Index(stages, a.From) {
+		errs = append(errs, fmt.Errorf("--stop-after must not be before --from (got %s, %s)", a.StopAfter, a.From))
+	}
+	return errors.Join(errs...)
+}
+
+// cd-ripに渡す引数
+func (a Arguments) ripArguments() []string {
+	result := []string{}
+	for _, value := range []struct {
+		key   string
+		value string
+	}{
+		{key: "--drive", value: a.Drive},
+		{key: "--verify", value: a.Verify},
+		{key: "--offset", value: a.Offset},
+		{key: "--read-mode", value: a.ReadMode},
+		{key: "--retries", value: a.Retries},
+		{key: "--retry-backoff", value: a.RetryBackoff},
+		{key: "--retry-speed", value: a.RetrySpeed},
+		{key: "--on-error", value: a.OnError},
+	} {
+		if value.value != "" {
+			result = append(result, value.key+"="+value.value)
+		}
+	}
+	return append(
+		result,
+		"--output="+a.Output,
+		"--mode=image",
+		"--tracks=all",
+		"--name="+imageFileName,
+	)
+}
@@ -0,0 +1,309 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/commands/cdrip"
+	"github.com/ryo-kagawa/Music/commands/convertflac"
+	"github.com/ryo-kagawa/Music/commands/verifylog"
+	"github.com/ryo-kagawa/Music/commands/wavesplitcue"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/Music/types/riplog"
+	"github.com/ryo-kagawa/Music/types/timecode"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const (
+	imageFileName  = "image.wav"
+	reportFileName = "archive-report.txt"
+)
+
+// 処理の段階(実行順)
+var stages = []string{
+	"rip",
+	"cue",
+	"split",
+	"encode",
+	"tag",
+	"verify",
+	"report",
+}
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "archive"
+}
+
+// 取り込みからFLACの検証までを順に実行する
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := commandline.ArgumentsParse[Arguments](arguments)
+	if err != nil {
+		return "", commands.UsageError(err.Error(), usage)
+	}
+	if args.Help {
+		return usage, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := os.MkdirAll(args.Output, 0755); err != nil {
+		return "", err
+	}
+	statePath := filepath.Join(args.Output, stateFileName)
+	s, err := loadState(statePath)
+	if err != nil {
+		return "", err
+	}
+	if args.From != "" {
+		s.reset(args.From)
+	}
+
+	a := archiver{
+		Command: c,
+		args:    args,
+		state:   &s,
+	}
+	for _, stage := range stages {
+		if _, ok := s.Stages[stage]; ok {
+			c.Global.Logf("skip: %s", stage)
+		} else {
+			fmt.Fprintf(c.Global.Progress(), "[%s]\n", stage)
+			result, err := a.run(ctx, stage)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", stage, err)
+			}
+			s.Stages[stage] = stageState{
+				Finished: time.Now(),
+				Result:   result,
+			}
+			if err := s.save(statePath); err != nil {
+				return "", err
+			}
+		}
+		if stage == args.StopAfter {
+			return fmt.Sprintf("stopped after %s\n", stage), nil
+		}
+	}
+
+	return filepath.Join(args.Output, reportFileName), nil
+}
+
+type archiver struct {
+	Command
+	args  Arguments
+	state *state
+}
+
+func (a archiver) imageCuePath() string {
+	return filepath.Join(a.args.Output, strings.TrimSuffix(imageFileName, filepath.Ext(imageFileName))+".cue")
+}
+
+func (a archiver) logPath() string {
+	return filepath.Join(a.args.Output, strings.TrimSuffix(imageFileName, filepath.Ext(imageFileName))+".log")
+}
+
+func (a archiver) run(ctx context.Context, stage string) (string, error) {
+	switch stage {
+	case "rip":
+		return a.rip(ctx)
+	case "cue":
+		return a.cue()
+	case "split":
+		return a.split()
+	case "encode":
+		return a.encode()
+	case "tag":
+		return a.tag(ctx)
+	case "verify":
+		return a.verify()
+	case "report":
+		return a.report()
+	}
+	return "", fmt.Errorf("unknown stage: %s", stage)
+}
+
+func (a archiver) rip(ctx context.Context) (string, error) {
+	args, err := cdrip.ParseArguments(a.Global.Config, a.args.ripArguments())
+	if err != nil {
+		return "", err
+	}
+	result, err := cdrip.Command{Global: a.Global}.Rip(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	a.state.Tracks = result.Tracks
+	a.state.ErrorMap = result.ErrorMap
+	return fmt.Sprintf("%d tracks: %s\n%d error ranges", len(result.Tracks), strings.Join(result.Outputs, ", "), len(result.ErrorMap)), nil
+}
+
+func (a archiver) cue() (string, error) {
+	if len(a.state.Tracks) == 0 {
+		return "", fmt.Errorf("track information not found, run again from rip")
+	}
+	discTracks := []cue.DiscTrack{}
+	for _, track := range a.state.Tracks {
+		discTracks = append(
+			discTracks,
+			cue.DiscTrack{
+				Number: track.Number,
+				// NOTE: イメージは先頭のトラックから読み込んでいる
+				Start:                track.StartLBA - a.state.Tracks[0].StartLBA,
+				PreEmphasisEnabled:   track.HasAudioWithPreEmphasis(),
+				DigitalCopyPermitted: track.HasDigitalCopyPermited(),
+				FourChannelAudio:     track.HasTwoFourChannelAudio(),
+				DataTrack:            track.HasAudioDataTrack(),
+			},
+		)
+	}
+	cueFile := cue.FromDisc(imageFileName, a.args.Title, a.args.Performer, discTracks)
+	for _, errorRange := range a.state.ErrorMap {
+		cueFile.Album.Field.Rem.ReadErrors = append(
+			cueFile.Album.Field.Rem.ReadErrors,
+			fmt.Sprintf(
+				"%s %s-%s",
+				errorRange.Kind,
+				timecode.Timecode(errorRange.StartLBA-a.state.Tracks[0].StartLBA),
+				timecode.Timecode(errorRange.EndLBA-1-a.state.Tracks[0].StartLBA),
+			),
+		)
+	}
+	cuePath := a.imageCuePath()
+	if err := cueFile.OutputCuefile(cuePath); err != nil {
+		return "", err
+	}
+	return cuePath, nil
+}
+
+func (a archiver) split() (string, error) {
+	splitCuePath, err := wavesplitcue.Command{Global: a.Global}.Split(
+		wavesplitcue.Arguments{
+			CuePath:    a.imageCuePath(),
+			Deemphasis: a.args.Deemphasis,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	a.state.SplitCuePath = splitCuePath
+	return splitCuePath, nil
+}
+
+func (a archiver) encode() (string, error) {
+	if err := (convertflac.Command{Global: a.Global}).Convert(a.state.SplitCuePath); err != nil {
+		return "", err
+	}
+	return a.state.SplitCuePath, nil
+}
+
+// FLACに置き換えた後のCUEシートからFLACファイルのパスとタグを取得する
+func (a archiver) flacFiles() (cue.Cue, []string, error) {
+	cueFile, err := cue.Load(a.state.SplitCuePath)
+	if err != nil {
+		return cue.Cue{}, nil, err
+	}
+	files := []string{}
+	for _, file := range cueFile.Album.Command.Files {
+		files = append(files, filepath.Join(filepath.Dir(a.state.SplitCuePath), file.Name))
+	}
+	return cueFile, files, nil
+}
+
+func (a archiver) tag(ctx context.Context) (string, error) {
+	cueFile, files, err := a.flacFiles()
+	if err != nil {
+		return "", err
+	}
+	tracks := []cue.Track{}
+	for _, file := range cueFile.Album.Command.Files {
+		tracks = append(tracks, file.Tracks...)
+	}
+	for i, track := range tracks {
+		arguments := []string{"--remove-all-tags"}
+		for _, tag := range cueFile.Tags(track) {
+			arguments = append(arguments, fmt.Sprintf("--set-tag=%s=%s", tag.Name, tag.Value))
+		}
+		cmd := exec.CommandContext(ctx, a.Global.Config.MetaflacExePath, append(arguments, files[i])...)
+		cmd.Stdout = a.Global.Progress()
+		cmd.Stderr = a.Global.Progress()
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s: %w", filepath.Base(files[i]), err)
+		}
+		a.Global.Logf("tag: %s", filepath.Base(files[i]))
+	}
+	return fmt.Sprintf("%d files", len(files)), nil
+}
+
+func (a archiver) verify() (string, error) {
+	log, err := riplog.Load(a.logPath())
+	if err != nil {
+		return "", err
+	}
+	_, files, err := a.flacFiles()
+	if err != nil {
+		return "", err
+	}
+	deemphasized, err := a.deemphasizedTracks()
+	if err != nil {
+		return "", err
+	}
+	result, err := verifylog.Command{Global: a.Global}.Verify(log, files, deemphasized)
+	if err != nil {
+		return "", fmt.Errorf("%w\n%s", err, result)
+	}
+	return result, nil
+}
+
+// ディエンファシスを適用したトラックの番号
+// NOTE: 分割後のCUEシートではPREフラグを解除するため、分割前のCUEシートから求める
+func (a archiver) deemphasizedTracks() ([]int, error) {
+	if !a.args.Deemphasis {
+		return nil, nil
+	}
+	cueFile, err := cue.Load(a.imageCuePath())
+	if err != nil {
+		return nil, err
+	}
+	result := []int{}
+	for _, file := range cueFile.Album.Command.Files {
+		for _, track := range file.Tracks {
+			if track.Field.Flags.PreEmphasisEnabled {
+				result = append(result, track.Command.Track)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (a archiver) report() (string, error) {
+	output := "music archive report\n\n"
+	for _, stage := range stages {
+		s, ok := a.state.Stages[stage]
+		if !ok {
+			continue
+		}
+		output += fmt.Sprintf("[%s] %s\n", stage, s.Finished.Format(time.DateTime))
+		if s.Result != "" {
+			output += strings.TrimRight(s.Result, "\n") + "\n"
+		}
+		output += "\n"
+	}
+	reportPath := filepath.Join(a.args.Output, reportFileName)
+	if err := os.WriteFile(reportPath, []byte(output), 0644); err != nil {
+		return "", err
+	}
+	return reportPath, nil
+}
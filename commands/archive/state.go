@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ryo-kagawa/Music/types/cdda"
+	"github.com/ryo-kagawa/Music/types/riplog"
+)
+
+const stateFileName = "archive.json"
+
+// 各段階の実行結果
+type stageState struct {
+	Finished time.Time `json:"finished"`
+	// 段階毎の出力(レポートに記載する)
+	Result string `json:"result"`
+}
+
+// 中断・再開のための処理状況
+type state struct {
+	Stages map[string]stageState `json:"stages"`
+	// 読み込んだトラック(CUEシートの作成に使用する)
+	Tracks []cdda.Track `json:"tracks"`
+	// 読み込みに問題があった範囲(CUEシートに記録する)
+	ErrorMap []riplog.ErrorRange `json:"errorMap"`
+	// 分割後のCUEシート
+	SplitCuePath string `json:"splitCuePath"`
+}
+
+func loadState(path string) (state, error) {
+	result := state{
+		Stages: map[string]stageState{},
+	}
+	binary, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return state{}, err
+	}
+	if err := json.Unmarshal(binary, &result); err != nil {
+		return state{}, err
+	}
+	if result.Stages == nil {
+		result.Stages = map[string]stageState{}
+	}
+	return result, nil
+}
+
+// NOTE: 書き込み途中で中断しても壊れないよう一時ファイルから置き換える
+func (s state) save(path string) error {
+	binary, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", binary, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// 指定した段階以降の実行結果を破棄する
+func (s *state) reset(from string) {
+	for _, stage := range stages[slices.Index(stages, from):] {
+		delete(s.Stages, stage)
+	}
+}
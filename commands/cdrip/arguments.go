@@ -0,0 +1,172 @@
+package cdrip
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/config"
+	"github.com/ryo-kagawa/Music/types/cdda"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music cd-rip --drive=<drive> [options]
+
+options:
+  --drive=<drive>       drive letter to read (e.g. D:)
+  --verify=<count>      number of verification passes after reading (default: 1)
+  --offset=<samples>    read offset correction in samples (default: 0)
+  --tracks=<tracks>     tracks to rip, e.g. 3-5,9 (default: all)
+  --mode=<mode>         output mode: image or track (default: image)
+  --output=<directory>  output directory (default: .)
+  --name=<name>         output file name, {track} is replaced with the track number
+                        (required in the name for track mode)
+                        (default: file.wav for image, Track {track}.wav for track)
+  --read-mode=<mode>    read mode: burst or secure (default: burst)
+  --speed=<x>           read speed, 0 for the drive maximum (default: 0)
+  --retries=<count>     retries for each unreadable sector (default: 3)
+  --retry-backoff=<ms>  wait before the first retry, doubled on each retry (default: 0)
+  --retry-speed=<x>     read speed while retrying, restored to --speed afterwards,
+                        0 keeps the current speed (default: 0)
+  --on-error=<action>   when a sector cannot be read: fail or silence (default: fail)
+  --help                show this help
+
+Defaults can be overridden with the "cdRip" section of config.json.
+`
+
+type Arguments struct {
+	Help     bool   `key:"--help"`
+	Drive    string `key:"--drive"`
+	Verify   int    `key:"--verify" default:"1"`
+	Offset   int    `key:"--offset" default:"0"`
+	Tracks   string `key:"--tracks" default:"all"`
+	Mode     string `key:"--mode" default:"image"`
+	Output   string `key:"--output" default:"."`
+	Name     string `key:"--name"`
+	ReadMode string `key:"--read-mode" default:"burst"`
+	Speed    int    `key:"--speed" default:"0"`
+	Retries  int    `key:"--retries" default:"3"`
+	// 再試行までの待ち時間(ミリ秒)
+	RetryBackoff int    `key:"--retry-backoff" default:"0"`
+	RetrySpeed   int    `key:"--retry-speed" default:"0"`
+	OnError      string `key:"--on-error" default:"fail"`
+}
+
+var driveLetterPattern = regexp.MustCompile(`^[A-Za-z]:$`)
+
+func (a *Arguments) Validate() error {
+	if a.Help {
+		return nil
+	}
+	errs := []error{}
+	if a.Drive == "" {
+		errs = append(errs, errors.New("--drive is required"))
+	} else if !driveLetterPattern.MatchString(a.Drive) {
+		errs = append(errs, fmt.Errorf("--drive must be a drive letter such as D: (got %s)", a.Drive))
+	}
+	if a.Verify < 0 {
+		errs = append(errs, fmt.Errorf("--verify must not be negative (got %d)", a.Verify))
+	}
+	if a.Speed < 0 {
+		errs = append(errs, fmt.Errorf("--speed must not be negative (got %d)", a.Speed))
+	}
+	if a.Retries < 0 {
+		errs = append(errs, fmt.Errorf("--retries must not be negative (got %d)", a.Retries))
+	}
+	if a.RetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("--retry-backoff must not be negative (got %d)", a.RetryBackoff))
+	}
+	if a.RetrySpeed < 0 {
+		errs = append(errs, fmt.Errorf("--retry-speed must not be negative (got %d)", a.RetrySpeed))
+	}
+	switch cdda.ErrorAction(a.OnError) {
+	case cdda.ErrorActionFail, cdda.ErrorActionSilence:
+	default:
+		errs = append(errs, fmt.Errorf("--on-error must be fail or silence (got %s)", a.OnError))
+	}
+	switch a.Mode {
+	case "image":
+	case "track":
+		// NOTE: トラック番号を含まない場合は全てのトラックが同じファイルに上書きされる
+		if a.Name != "" && !strings.Contains(a.Name, "{track}") {
+			errs = append(errs, fmt.Errorf("--name must contain {track} in track mode (got %s)", a.Name))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("--mode must be image or track (got %s)", a.Mode))
+	}
+	switch cdda.ReadMode(a.ReadMode) {
+	case cdda.ReadModeBurst, cdda.ReadModeSecure:
+	default:
+		errs = append(errs, fmt.Errorf("--read-mode must be burst or secure (got %s)", a.ReadMode))
+	}
+	return errors.Join(errs...)
+}
+
+func (a Arguments) FileName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	if a.Mode == "track" {
+		return "Track {track}.wav"
+	}
+	return "file.wav"
+}
+
+func (a Arguments) RetryPolicy() cdda.RetryPolicy {
+	return cdda.RetryPolicy{
+		Retries:      a.Retries,
+		Backoff:      time.Duration(a.RetryBackoff) * time.Millisecond,
+		ReducedSpeed: a.RetrySpeed,
+		OnError:      cdda.ErrorAction(a.OnError),
+	}
+}
+
+// 設定ファイルの値を既定値として引数を解釈する
+func ParseArguments(c config.Config, arguments []string) (Arguments, error) {
+	args, err := commandline.ArgumentsParse[Arguments](append(configArguments(c), arguments...))
+	if err != nil {
+		return Arguments{}, commands.UsageError(err.Error(), usage)
+	}
+	return args, nil
+}
+
+// 設定ファイルの値を引数の形式に変換する
+// NOTE: コマンドラインの引数より前に置くことで、コマンドラインの指定を優先させる
+func configArguments(c config.Config) []string {
+	result := []string{}
+	if c.CdRip.Drive != "" {
+		result = append(result, "--drive="+c.CdRip.Drive)
+	}
+	if c.CdRip.Verify != nil {
+		result = append(result, "--verify="+strconv.Itoa(*c.CdRip.Verify))
+	}
+	if c.CdRip.Offset != nil {
+		result = append(result, "--offset="+strconv.Itoa(*c.CdRip.Offset))
+	}
+	if c.CdRip.Output != "" {
+		result = append(result, "--output="+c.CdRip.Output)
+	}
+	if c.CdRip.ReadMode != "" {
+		result = append(result, "--read-mode="+c.CdRip.ReadMode)
+	}
+	if c.CdRip.Speed != nil {
+		result = append(result, "--speed="+strconv.Itoa(*c.CdRip.Speed))
+	}
+	if c.CdRip.Retries != nil {
+		result = append(result, "--retries="+strconv.Itoa(*c.CdRip.Retries))
+	}
+	if c.CdRip.RetryBackoff != nil {
+		result = append(result, "--retry-backoff="+strconv.Itoa(*c.CdRip.RetryBackoff))
+	}
+	if c.CdRip.RetrySpeed != nil {
+		result = append(result, "--retry-speed="+strconv.Itoa(*c.CdRip.RetrySpeed))
+	}
+	if c.CdRip.OnError != "" {
+		result = append(result, "--on-error="+c.CdRip.OnError)
+	}
+	return result
+}
@@ -0,0 +1,197 @@
+package cdrip
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cdda"
+	"github.com/ryo-kagawa/Music/types/riplog"
+	"github.com/ryo-kagawa/go-utils/commandline"
+	"golang.org/x/sys/windows"
+)
+
+// Channels * Bit Depth
+const sampleSize = 2 * 16 / 8
+const IOCTL_STORAGE_EJECT_MEDIA = 0x002D4808
+const IOCTL_STORAGE_LOAD_MEDIA = 0x002D480C
+
+type RAW_READ_INFO struct {
+	DiskOffset  int64
+	SectorCount uint32
+	TrackMode   uint32
+}
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "cd-rip"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := ParseArguments(c.Global.Config, arguments)
+	if err != nil {
+		return "", err
+	}
+	if args.Help {
+		return usage, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if _, err := c.Rip(ctx, args); err != nil {
+		return "", err
+	}
+
+	return "finish", nil
+}
+
+// 読み込み結果
+type Result struct {
+	// 出力したWAVEファイル
+	Outputs []string
+	// ログの出力先(拡張子なし)
+	LogBasePath string
+	// 読み込んだトラック
+	Tracks []cdda.Track
+	// 読み込みに問題があった範囲
+	ErrorMap []riplog.ErrorRange
+}
+
+func (c Command) Rip(ctx context.Context, args Arguments) (Result, error) {
+	// Win32 Device Namespaces
+	win32DeviceNamespaces := "\\\\.\\" + args.Drive
+	win32DeviceNamespacesPtr, err := windows.UTF16PtrFromString(win32DeviceNamespaces)
+	if err != nil {
+		return Result{}, err
+	}
+	handle, err := windows.CreateFile(
+		win32DeviceNamespacesPtr,
+		windows.GENERIC_READ,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE,
+		nil,
+		windows.OPEN_EXISTING,
+		windows.FILE_ATTRIBUTE_NORMAL|windows.FILE_FLAG_NO_BUFFERING,
+		0,
+	)
+	if err != nil {
+		return Result{}, err
+	}
+	defer windows.CloseHandle(handle)
+
+	closeTray(handle)
+	if !waitReadReady(ctx, args.Drive) {
+		return Result{}, fmt.Errorf("not read disc")
+	}
+
+	toc, err := cdda.ReadTOC(handle)
+	if err != nil {
+		return Result{}, err
+	}
+	selectedTracks, err := parseTrackSelection(args.Tracks, toc.Tracks())
+	if err != nil {
+		return Result{}, err
+	}
+	c.Global.Logf("disc: %d tracks, %d sectors", len(toc.Tracks()), toc.SectorCount())
+	if err := os.MkdirAll(args.Output, 0755); err != nil {
+		return Result{}, err
+	}
+	outputs := []*output{}
+	logBasePath := filepath.Join(args.Output, "rip")
+	switch args.Mode {
+	case "image":
+		if !isContiguous(selectedTracks) {
+			return Result{}, fmt.Errorf("image requires contiguous tracks: %s", args.Tracks)
+		}
+		outputPath := filepath.Join(args.Output, trackFileName(args.FileName(), selectedTracks[0].Number))
+		outputs = append(outputs, newOutput(outputPath, selectedTracks[0].StartLBA, selectedTracks[len(selectedTracks)-1].EndLBA))
+		logBasePath = strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	case "track":
+		for _, track := range selectedTracks {
+			outputs = append(outputs, newOutput(filepath.Join(args.Output, trackFileName(args.FileName(), track.Number)), track.StartLBA, track.EndLBA))
+		}
+	}
+
+	r, err := newRipper(handle, args, toc, selectedTracks, outputs, logBasePath+".journal", c.Global.Progress())
+	if err != nil {
+		return Result{}, err
+	}
+	c.Global.Logf("read: lba %d, verified %d/%d", r.journal.NextLBA, r.journal.VerifiedCount, args.Verify)
+	if err := r.Rip(ctx, args.Verify); err != nil {
+		return Result{}, err
+	}
+	drive, err := cdda.ReadDrive(handle)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := r.WriteLog(ctx, drive, args.Verify, logBasePath, []byte(c.Global.Config.LogKey)); err != nil {
+		return Result{}, err
+	}
+	// NOTE: ログの出力まで完了したら再開用の情報は不要となる
+	if err := os.Remove(r.journalPath); err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Outputs:     r.journal.Outputs,
+		LogBasePath: logBasePath,
+		Tracks:      selectedTracks,
+		ErrorMap:    r.errorMap(),
+	}, nil
+}
+
+func closeTray(handle windows.Handle) error {
+	return windows.DeviceIoControl(
+		handle,
+		IOCTL_STORAGE_LOAD_MEDIA,
+		nil,
+		0,
+		nil,
+		0,
+		new(uint32),
+		nil,
+	)
+}
+
+func ejectTray(handle windows.Handle) error {
+	return windows.DeviceIoControl(
+		handle,
+		IOCTL_STORAGE_EJECT_MEDIA,
+		nil,
+		0,
+		nil,
+		0,
+		new(uint32),
+		nil,
+	)
+}
+
+func waitReadReady(ctx context.Context, driveLetter string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := os.Stat(driveLetter); err == nil {
+				return true
+			}
+		case <-ctx.Done():
+			if _, err := os.Stat(driveLetter); err == nil {
+				return true
+			}
+			return false
+		}
+	}
+}
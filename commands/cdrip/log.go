@@ -0,0 +1,172 @@
+package cdrip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ryo-kagawa/Music/types/accuraterip"
+	"github.com/ryo-kagawa/Music/types/cdda"
+	"github.com/ryo-kagawa/Music/types/riplog"
+	"github.com/ryo-kagawa/go-utils/arrays"
+)
+
+// 補正後の位置で順に書き込まれるデータをトラック毎に振り分ける
+type trackWriter struct {
+	tracks  []cdda.Track
+	writers []io.Writer
+}
+
+func (t trackWriter) WriteAt(p []byte, position int64) {
+	for i, track := range t.tracks {
+		start := int64(track.StartLBA) * cdda.RAW_SECTOR_SIZE
+		end := int64(track.EndLBA) * cdda.RAW_SECTOR_SIZE
+		from := max(start, position)
+		to := min(end, position+int64(len(p)))
+		if from < to {
+			t.writers[i].Write(p[from-position : to-position])
+		}
+	}
+}
+
+func newTestCRCWriter(tracks []cdda.Track) (trackWriter, []hash.Hash32) {
+	hashes := make([]hash.Hash32, len(tracks))
+	writers := make([]io.Writer, len(tracks))
+	for i := range tracks {
+		hashes[i] = crc32.NewIEEE()
+		writers[i] = hashes[i]
+	}
+	return trackWriter{
+		tracks:  tracks,
+		writers: writers,
+	}, hashes
+}
+
+// トラックを含む出力先と、出力先の音声データ内での位置を返す
+func (r *ripper) findOutput(track cdda.Track) (*output, int64, bool) {
+	start := int64(track.StartLBA) * cdda.RAW_SECTOR_SIZE
+	end := int64(track.EndLBA) * cdda.RAW_SECTOR_SIZE
+	for _, o := range r.outputs {
+		if o.start <= start && end <= o.start+o.size {
+			return o, start - o.start, true
+		}
+	}
+	return nil, 0, false
+}
+
+// 出力したWAVEファイルを読み込み、ログをlogBasePathに拡張子を付与したパスへ出力する
+func (r *ripper) WriteLog(ctx context.Context, drive cdda.Drive, verifyCount int, logBasePath string, key []byte) error {
+	log := riplog.Log{
+		Application: "cd-rip",
+		Date:        time.Now(),
+		Drive:       drive.String(),
+		ReadOffset:  r.journal.OffsetSample,
+		ReadMode:    string(r.readMode),
+		VerifyCount: verifyCount,
+		TOC: arrays.Map(
+			r.tracks,
+			func(track cdda.Track) riplog.TOCEntry {
+				return riplog.TOCEntry{
+					Track:    track.Number,
+					StartLBA: track.StartLBA,
+					EndLBA:   track.EndLBA,
+				}
+			},
+		),
+		ErrorMap: r.errorMap(),
+	}
+
+	discID := accuraterip.NewDiscID(
+		arrays.Map(
+			r.tracks,
+			func(track cdda.Track) int {
+				return track.StartLBA
+			},
+		),
+		r.journal.SectorCount,
+	)
+	entries, lookupErr := accuraterip.Lookup(ctx, discID)
+	switch {
+	case errors.Is(lookupErr, accuraterip.ErrorNotFound):
+		log.AccurateRip = "disc not present in database"
+	case lookupErr != nil:
+		log.AccurateRip = "lookup failed: " + lookupErr.Error()
+	default:
+		log.AccurateRip = "found in database"
+	}
+
+	for i, track := range r.selectedTracks {
+		o, position, ok := r.findOutput(track)
+		if !ok {
+			return fmt.Errorf("track: %d output not found", track.Number)
+		}
+		// NOTE: AccurateRipはディスク上の先頭・最終トラックで計算範囲が異なる
+		discIndex := slices.IndexFunc(r.tracks, func(t cdda.Track) bool {
+			return t.Number == track.Number
+		})
+		analyzer := riplog.NewAnalyzer(
+			track.SectorCount()*cdda.RAW_SECTOR_SIZE/sampleSize,
+			discIndex == 0,
+			discIndex == len(r.tracks)-1,
+		)
+		if err := analyzeOutput(analyzer, o.path, position, int64(track.SectorCount())*cdda.RAW_SECTOR_SIZE); err != nil {
+			return err
+		}
+		logTrack := riplog.Track{
+			Number:   track.Number,
+			Filename: filepath.Base(o.path),
+			Peak:     analyzer.Peak(),
+			CopyCRC:  analyzer.CRC(),
+		}
+		if i < len(r.journal.TestCRCs) {
+			logTrack.TestCRC = r.journal.TestCRCs[i]
+		}
+		if lookupErr == nil || errors.Is(lookupErr, accuraterip.ErrorNotFound) {
+			result := accuraterip.Match(entries, discIndex, analyzer.AccurateRip())
+			logTrack.AccurateRip = &riplog.AccurateRip{
+				V1:         riplog.FormatCRC(result.Checksum.V1),
+				V2:         riplog.FormatCRC(result.Checksum.V2),
+				Version:    result.Version,
+				Confidence: result.Confidence,
+				Total:      result.Total,
+			}
+		}
+		for _, lba := range r.journal.Suspicious {
+			if track.StartLBA <= lba && lba < track.EndLBA {
+				logTrack.Suspicious = append(logTrack.Suspicious, lba)
+			}
+		}
+		for _, lba := range r.journal.Unreadable {
+			if track.StartLBA <= lba && lba < track.EndLBA {
+				logTrack.Unreadable = append(logTrack.Unreadable, lba)
+			}
+		}
+		log.Tracks = append(log.Tracks, logTrack)
+	}
+
+	if err := os.WriteFile(logBasePath+".log", []byte(log.Text(key)), 0644); err != nil {
+		return err
+	}
+	value, err := log.JSON(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(logBasePath+".json", value, 0644)
+}
+
+func analyzeOutput(analyzer *riplog.Analyzer, path string, position int64, size int64) error {
+	waveFile, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer waveFile.Close()
+	_, err = io.Copy(analyzer, io.NewSectionReader(waveFile, headerSize+position, size))
+	return err
+}
@@ -0,0 +1,55 @@
+package cdrip
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ryo-kagawa/Music/types/cdda"
+)
+
+// 進捗表示を更新する間隔
+const progressInterval = 200 * time.Millisecond
+
+// 進捗を1行で上書き表示する
+type progressPrinter struct {
+	writer     io.Writer
+	label      string
+	lastUpdate time.Time
+}
+
+func newProgressPrinter(writer io.Writer, label string) *progressPrinter {
+	return &progressPrinter{
+		writer: writer,
+		label:  label,
+	}
+}
+
+func (p *progressPrinter) Update(progress cdda.Progress) {
+	if progress.LBA != progress.EndLBA && time.Since(p.lastUpdate) < progressInterval {
+		return
+	}
+	p.lastUpdate = time.Now()
+	percent := 100.0
+	if progress.Total() != 0 {
+		percent = float64(progress.Done()) * 100 / float64(progress.Total())
+	}
+	fmt.Fprintf(
+		p.writer,
+		"\r%s: %5.1f%% lba %d/%d speed %.1fx retries %d ETA %s   ",
+		p.label,
+		percent,
+		progress.LBA,
+		progress.EndLBA,
+		progress.Speed,
+		progress.Retries,
+		progress.ETA.Round(time.Second),
+	)
+}
+
+// 進捗表示の行を確定する
+func (p *progressPrinter) Finish() {
+	if !p.lastUpdate.IsZero() {
+		fmt.Fprintln(p.writer)
+	}
+}
@@ -0,0 +1,366 @@
+package cdrip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/ryo-kagawa/Music/types/cdda"
+	"github.com/ryo-kagawa/Music/types/riplog"
+	"github.com/ryo-kagawa/Music/types/wave"
+	"github.com/ryo-kagawa/go-utils/arrays"
+	"golang.org/x/sys/windows"
+)
+
+// NOTE: fmt・data以外のチャンクを持たないため、音声データの位置は固定となる
+const headerSize = wave.CanonicalHeaderSize
+
+// 中断時に再開位置を記録する間隔(セクタ数)
+const checkpointInterval = 75 * 10
+
+// 中断した読み込みを再開するための進捗情報
+type journal struct {
+	SectorCount  int `json:"sectorCount"`
+	OffsetSample int `json:"offsetSample"`
+	// 出力先のファイル
+	Outputs       []string `json:"outputs"`
+	NextLBA       int      `json:"nextLBA"`
+	VerifiedCount int      `json:"verifiedCount"`
+	// 最後に照合した際のトラック毎のCRC
+	TestCRCs []string `json:"testCRCs"`
+	// 読み込みに再試行を要したセクタ
+	Suspicious []int `json:"suspicious"`
+	// 読み込めずに無音に置き換えたセクタ
+	Unreadable []int `json:"unreadable"`
+}
+
+func loadJournal(journalPath string) (journal, bool, error) {
+	value, err := os.ReadFile(journalPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return journal{}, false, nil
+	}
+	if err != nil {
+		return journal{}, false, err
+	}
+	result := journal{}
+	if err := json.Unmarshal(value, &result); err != nil {
+		return journal{}, false, err
+	}
+	return result, true, nil
+}
+
+func (j journal) save(journalPath string) error {
+	value, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	// NOTE: 書き込み途中で中断されても壊れたジャーナルが残らないように置き換える
+	tempPath := journalPath + ".tmp"
+	if err := os.WriteFile(tempPath, value, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, journalPath)
+}
+
+// 読み込みながら書き込む一時ファイルと、完了後に置き換える出力先
+type output struct {
+	path     string
+	partPath string
+	// 出力する範囲(オフセット補正後のディスク上の位置)
+	start int64
+	size  int64
+	file  *os.File
+}
+
+func newOutput(path string, startLBA int, endLBA int) *output {
+	return &output{
+		path:     path,
+		partPath: path + ".part",
+		start:    int64(startLBA) * cdda.RAW_SECTOR_SIZE,
+		size:     int64(endLBA-startLBA) * cdda.RAW_SECTOR_SIZE,
+	}
+}
+
+// ディスクを読み込みながら一時ファイルへ書き込み、完了後にWAVEファイルとして置き換える
+type ripper struct {
+	handle      windows.Handle
+	driveLetter string
+	outputs     []*output
+	journalPath string
+	// オフセット補正後のディスク全体のサイズ
+	discSize int64
+	// オフセット補正量(Byte)
+	// NOTE: 補正後の位置xのデータはディスク上の位置x+offsetのデータとなる
+	offset   int64
+	readMode cdda.ReadMode
+	// 読み込み速度(等速に対する倍率)、0の場合はドライブの最大速度
+	speed int
+	retry cdda.RetryPolicy
+	// ディスク上の全トラック
+	tracks []cdda.Track
+	// 読み込むトラック
+	selectedTracks []cdda.Track
+	// 進捗の出力先
+	progress io.Writer
+	journal  journal
+}
+
+func newRipper(handle windows.Handle, arguments Arguments, toc cdda.CDROM_TOC_FULL_TOC_DATA, selectedTracks []cdda.Track, outputs []*output, journalPath string, progress io.Writer) (*ripper, error) {
+	sectorCount := toc.SectorCount()
+	r := &ripper{
+		handle:         handle,
+		driveLetter:    arguments.Drive,
+		outputs:        outputs,
+		journalPath:    journalPath,
+		discSize:       int64(sectorCount) * cdda.RAW_SECTOR_SIZE,
+		offset:         int64(arguments.Offset) * sampleSize,
+		readMode:       cdda.ReadMode(arguments.ReadMode),
+		speed:          arguments.Speed,
+		retry:          arguments.RetryPolicy(),
+		tracks:         toc.Tracks(),
+		selectedTracks: selectedTracks,
+		progress:       progress,
+		journal: journal{
+			SectorCount:  sectorCount,
+			OffsetSample: arguments.Offset,
+			Outputs: arrays.Map(
+				outputs,
+				func(o *output) string {
+					return o.path
+				},
+			),
+		},
+	}
+	startLBA, _ := r.lbaRange()
+	r.journal.NextLBA = startLBA
+
+	saved, ok, err := loadJournal(r.journalPath)
+	if err != nil {
+		return nil, err
+	}
+	if ok && saved.SectorCount == r.journal.SectorCount && saved.OffsetSample == r.journal.OffsetSample && slices.Equal(saved.Outputs, r.journal.Outputs) {
+		resumable := true
+		for _, o := range outputs {
+			if _, err := os.Stat(o.partPath); err != nil {
+				resumable = false
+			}
+		}
+		if resumable {
+			r.journal = saved
+		}
+	}
+	return r, nil
+}
+
+// ディスク上のlbaのセクタをオフセット補正後の位置に変換し、ディスクの範囲に収まる部分に切り詰める
+func (r *ripper) placement(lba int, sector []byte) (int64, []byte) {
+	return clip(int64(lba)*cdda.RAW_SECTOR_SIZE-r.offset, sector, 0, r.discSize)
+}
+
+// オフセット補正によりディスク上のデータが無く、無音とする先頭・末尾の長さ(Byte)
+func (r *ripper) silence() (int64, int64) {
+	if r.offset < 0 {
+		return min(-r.offset, r.discSize), 0
+	}
+	return 0, min(r.offset, r.discSize)
+}
+
+// positionから始まるdataのうち、startからstart+sizeの範囲に収まる部分を返す
+func clip(position int64, data []byte, start int64, size int64) (int64, []byte) {
+	from := max(position, start)
+	to := min(position+int64(len(data)), start+size)
+	if to <= from {
+		return from, nil
+	}
+	return from, data[from-position : to-position]
+}
+
+// 出力先のいずれかに含まれるディスク上のセクタ範囲を返す
+func (r *ripper) lbaRange() (int, int) {
+	from := r.discSize
+	to := int64(0)
+	for _, o := range r.outputs {
+		from = min(from, o.start)
+		to = max(to, o.start+o.size)
+	}
+	startLBA := max(0, int((from+r.offset)/cdda.RAW_SECTOR_SIZE))
+	endLBA := min(r.journal.SectorCount, int((to+r.offset+cdda.RAW_SECTOR_SIZE-1)/cdda.RAW_SECTOR_SIZE))
+	return startLBA, endLBA
+}
+
+func (r *ripper) Rip(ctx context.Context, verifyCount int) error {
+	startLBA, endLBA := r.lbaRange()
+	flag := os.O_RDWR | os.O_CREATE
+	if r.journal.NextLBA == startLBA && r.journal.VerifiedCount == 0 {
+		flag |= os.O_TRUNC
+	}
+	for _, o := range r.outputs {
+		file, err := os.OpenFile(o.partPath, flag, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		o.file = file
+	}
+
+	if r.journal.NextLBA < endLBA {
+		printer := newProgressPrinter(r.progress, "read")
+		nextLBA := r.journal.NextLBA
+		option := cdda.ReadOption{
+			Mode:     r.readMode,
+			Speed:    r.speed,
+			Retry:    r.retry,
+			Progress: printer.Update,
+			Retried: func(lba int, retries int) {
+				// NOTE: 再開時に同じセクタを再度読み込む場合があるため重複させない
+				if !slices.Contains(r.journal.Suspicious, lba) {
+					r.journal.Suspicious = append(r.journal.Suspicious, lba)
+				}
+			},
+			Unreadable: func(lba int, err error) {
+				fmt.Fprintf(r.progress, "\n%v, replaced with silence\n", err)
+				if !slices.Contains(r.journal.Unreadable, lba) {
+					r.journal.Unreadable = append(r.journal.Unreadable, lba)
+				}
+			},
+		}
+		err := cdda.ReadSectors(ctx, r.handle, r.journal.NextLBA, endLBA, option, func(lba int, sector []byte) error {
+			position, data := r.placement(lba, sector)
+			for _, o := range r.outputs {
+				outputPosition, outputData := clip(position, data, o.start, o.size)
+				if len(outputData) != 0 {
+					if _, err := o.file.WriteAt(outputData, headerSize+outputPosition-o.start); err != nil {
+						return err
+					}
+				}
+			}
+			nextLBA = lba + 1
+			if nextLBA%checkpointInterval == 0 {
+				return r.checkpoint(nextLBA)
+			}
+			return nil
+		})
+		printer.Finish()
+		if errors.Is(err, context.Canceled) {
+			// NOTE: 読み込み済みの位置から再開できるように記録してから中断する
+			if err := r.checkpoint(nextLBA); err != nil {
+				return err
+			}
+			return fmt.Errorf("interrupted at lba: %d, run again to resume: %w", nextLBA, err)
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.checkpoint(endLBA); err != nil {
+			return err
+		}
+	}
+
+	for r.journal.VerifiedCount < verifyCount {
+		ejectTray(r.handle)
+		closeTray(r.handle)
+		if !waitReadReady(ctx, r.driveLetter) {
+			return fmt.Errorf("not read disc")
+		}
+		printer := newProgressPrinter(r.progress, fmt.Sprintf("verify %d/%d", r.journal.VerifiedCount+1, verifyCount))
+		buffer := make([]byte, cdda.RAW_SECTOR_SIZE)
+		testCRCWriter, testCRCs := newTestCRCWriter(r.selectedTracks)
+		// NOTE: コピーのCRCは出力したファイルから求めるため、無音とした範囲もCRCに含める
+		head, tail := r.silence()
+		testCRCWriter.WriteAt(make([]byte, head), 0)
+		err := cdda.ReadSectors(ctx, r.handle, startLBA, endLBA, cdda.ReadOption{Mode: r.readMode, Speed: r.speed, Retry: r.retry, Progress: printer.Update}, func(lba int, sector []byte) error {
+			position, data := r.placement(lba, sector)
+			// NOTE: 無音に置き換えたセクタはコピーと同じく無音としてCRCを求め、照合しない
+			if slices.Contains(r.journal.Unreadable, lba) {
+				testCRCWriter.WriteAt(make([]byte, len(data)), position)
+				return nil
+			}
+			testCRCWriter.WriteAt(data, position)
+			for _, o := range r.outputs {
+				outputPosition, outputData := clip(position, data, o.start, o.size)
+				if len(outputData) == 0 {
+					continue
+				}
+				if _, err := o.file.ReadAt(buffer[:len(outputData)], headerSize+outputPosition-o.start); err != nil {
+					return err
+				}
+				if !bytes.Equal(buffer[:len(outputData)], outputData) {
+					return fmt.Errorf("verify error: lba: %d not match", lba)
+				}
+			}
+			return nil
+		})
+		printer.Finish()
+		testCRCWriter.WriteAt(make([]byte, tail), r.discSize-tail)
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("interrupted while verifying, run again to resume: %w", err)
+		}
+		if err != nil {
+			return err
+		}
+		r.journal.VerifiedCount++
+		r.journal.TestCRCs = arrays.Map(
+			testCRCs,
+			func(testCRC hash.Hash32) string {
+				return riplog.FormatCRC(testCRC.Sum32())
+			},
+		)
+		if err := r.journal.save(r.journalPath); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range r.outputs {
+		if err := o.finish(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 読み込みに問題があった範囲
+func (r *ripper) errorMap() []riplog.ErrorRange {
+	result := append(
+		riplog.NewErrorMap(riplog.ErrorKindSuspicious, r.journal.Suspicious),
+		riplog.NewErrorMap(riplog.ErrorKindUnreadable, r.journal.Unreadable)...,
+	)
+	slices.SortStableFunc(result, func(a riplog.ErrorRange, b riplog.ErrorRange) int {
+		return a.StartLBA - b.StartLBA
+	})
+	return result
+}
+
+func (r *ripper) checkpoint(nextLBA int) error {
+	for _, o := range r.outputs {
+		if err := o.file.Sync(); err != nil {
+			return err
+		}
+	}
+	r.journal.NextLBA = nextLBA
+	return r.journal.save(r.journalPath)
+}
+
+// ヘッダーを確定し、一時ファイルを出力先へ置き換える
+func (o *output) finish() error {
+	// NOTE: オフセット補正により書き込まれなかった範囲は無音とする
+	if err := o.file.Truncate(headerSize + o.size); err != nil {
+		return err
+	}
+	if _, err := o.file.WriteAt(wave.New(wave.CDDA()).Header(o.size), 0); err != nil {
+		return err
+	}
+	if err := o.file.Sync(); err != nil {
+		return err
+	}
+	if err := o.file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(o.partPath, o.path)
+}
@@ -0,0 +1,66 @@
+package cdrip
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/types/cdda"
+)
+
+// 「3-5,9」形式のトラック指定を解釈する
+// NOTE: 空文字または「all」の場合は全トラックとする
+func parseTrackSelection(value string, tracks []cdda.Track) ([]cdda.Track, error) {
+	if value == "" || value == "all" {
+		return tracks, nil
+	}
+	numbers := []int{}
+	for field := range strings.SplitSeq(value, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(field), "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid track selection: %s", field)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(to)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid track selection: %s", field)
+			}
+		}
+		for number := start; number <= end; number++ {
+			if !slices.Contains(numbers, number) {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	slices.Sort(numbers)
+
+	result := []cdda.Track{}
+	for _, number := range numbers {
+		index := slices.IndexFunc(tracks, func(track cdda.Track) bool {
+			return track.Number == number
+		})
+		if index < 0 {
+			return nil, fmt.Errorf("track: %d not found", number)
+		}
+		result = append(result, tracks[index])
+	}
+	return result, nil
+}
+
+// 選択したトラックが連続しているか
+func isContiguous(tracks []cdda.Track) bool {
+	for i := 1; i < len(tracks); i++ {
+		if tracks[i-1].EndLBA != tracks[i].StartLBA {
+			return false
+		}
+	}
+	return true
+}
+
+// 「{track}」をトラック番号(2桁)に置き換える
+func trackFileName(template string, number int) string {
+	return strings.ReplaceAll(template, "{track}", fmt.Sprintf("%02d", number))
+}
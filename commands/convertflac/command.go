@@ -0,0 +1,92 @@
+package convertflac
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/go-utils/arrays"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music convert-flac <cue file>`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "convert-flac"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	if len(arguments) != 1 {
+		return "", commands.UsageError("cue file is required", usage)
+	}
+	if err := c.Convert(arguments[0]); err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+// CUEシートのWAVEファイルをFLACに変換し、CUEシートのファイル名を置き換える
+func (c Command) Convert(cuePath string) error {
+	cueContents, err := cue.Load(cuePath)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: WAVE以外のファイルはそのままとする
+	waveFiles := []cue.File{}
+	for _, file := range cueContents.Album.Command.Files {
+		if file.Type == cue.FileTypeWave {
+			waveFiles = append(waveFiles, file)
+		}
+	}
+	if len(waveFiles) != 0 {
+		cmd := exec.Command(
+			c.Global.Config.FlacExePath,
+			append(
+				[]string{
+					"--force",
+					"--delete-input-file",
+					"--warnings-as-errors",
+					"--verify",
+					"--replay-gain",
+					"--compression-level-8",
+					"--no-padding",
+				},
+				arrays.Map(
+					waveFiles,
+					func(file cue.File) string {
+						return filepath.Join(filepath.Dir(cuePath), file.Name)
+					},
+				)...,
+			)...,
+		)
+		cmd.Stdout = c.Global.Progress()
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+
+	cueContents.Album.Command.Files = arrays.Map(
+		cueContents.Album.Command.Files,
+		func(file cue.File) cue.File {
+			if file.Type != cue.FileTypeWave {
+				return file
+			}
+			file.Name = strings.TrimSuffix(file.Name, filepath.Ext(file.Name)) + ".flac"
+			file.Type = cue.FileTypeFlac
+			return file
+		},
+	)
+	return cueContents.OutputCuefile(cuePath)
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ryo-kagawa/Music/types/cue"
+)
+
+// CUEシートの出力の書式の引数(--encoding・--line-ending)を確認する
+func ValidateWriteOptions(encoding string, lineEnding string) error {
+	if encoding != "" && !cue.IsEncoding(encoding) {
+		return fmt.Errorf("--encoding must be utf-8, utf-8-bom, shift-jis, euc-jp, utf-16le, utf-16be or latin-1 (got %s)", encoding)
+	}
+	switch lineEnding {
+	case "", "crlf", "lf":
+	default:
+		return fmt.Errorf("--line-ending must be crlf or lf (got %s)", lineEnding)
+	}
+	return nil
+}
+
+// CUEシートの出力の書式の引数を出力の書式に変換する
+func WriteOptions(encoding string, lineEnding string) cue.WriteOptions {
+	options := cue.WriteOptions{
+		Encoding: encoding,
+	}
+	switch lineEnding {
+	case "crlf":
+		options.LineEnding = cue.LineEndingCRLF
+	case "lf":
+		options.LineEnding = cue.LineEndingLF
+	}
+	return options
+}
+
+// CUEシートを読み込む
+// NOTE: 寛容モードでは警告を進捗の出力先に出力する
+func (g Global) LoadCue(cuePath string, lenient bool) (cue.Cue, error) {
+	if !lenient {
+		return cue.Load(cuePath)
+	}
+	cueFile, warnings, err := cue.LoadLenient(cuePath)
+	for _, warning := range warnings {
+		fmt.Fprintf(g.Progress(), "warning: %v\n", warning)
+	}
+	return cueFile, err
+}
@@ -0,0 +1,40 @@
+package cueexport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ryo-kagawa/Music/types/cue"
+)
+
+type Arguments struct {
+	Help bool `key:"--help"`
+	// json/yaml(未指定の場合は出力先の拡張子、それも無い場合はjson)
+	Format string `key:"--format"`
+	// 出力先(未指定の場合はCUEシートの拡張子を置き換えたパス、「-」は標準出力)
+	Output string `key:"--output"`
+	// 未対応の行を警告として読み飛ばす
+	Lenient bool `key:"--lenient"`
+	CuePath string
+}
+
+func (a *Arguments) Validate() error {
+	switch a.Format {
+	case "", cue.DocumentJSON, cue.DocumentYAML:
+	default:
+		return fmt.Errorf("--format must be json or yaml (got %s)", a.Format)
+	}
+	return nil
+}
+
+// NOTE: オプション以外の引数をCUEシートのパスとする
+func (a *Arguments) After(values []string) error {
+	if a.Help {
+		return nil
+	}
+	if len(values) != 1 {
+		return errors.New("cue file is required")
+	}
+	a.CuePath = values[0]
+	return nil
+}
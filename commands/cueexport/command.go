@@ -0,0 +1,76 @@
+package cueexport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music cue-export [options] <cue file>
+
+converts a cue sheet to JSON or YAML (schema version 2, see README)
+
+options:
+  --format=<fmt>   json or yaml (default: from the --output extension, otherwise json)
+  --output=<path>  output file, - for standard output
+                   (default: the cue file with a .json or .yaml extension)
+  --lenient        skip unsupported lines with a warning instead of failing
+  --help           show this help
+`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "cue-export"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := commandline.ArgumentsParse[Arguments](arguments)
+	if err != nil {
+		return "", commands.UsageError(err.Error(), usage)
+	}
+	if args.Help {
+		return usage, nil
+	}
+	return c.Export(args)
+}
+
+// CUEシートをJSON・YAMLに変換し、出力先のパスを返す
+// NOTE: 標準出力に出力する場合は内容を返す
+func (c Command) Export(args Arguments) (string, error) {
+	cueFile, err := c.Global.LoadCue(args.CuePath, args.Lenient)
+	if err != nil {
+		return "", err
+	}
+	format := args.Format
+	if format == "" {
+		format, _ = cue.DocumentFormat(args.Output)
+	}
+	if format == "" {
+		format = cue.DocumentJSON
+	}
+	binary, err := cueFile.MarshalDocument(format)
+	if err != nil {
+		return "", err
+	}
+	if args.Output == "-" {
+		return string(binary), nil
+	}
+	outputPath := args.Output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(args.CuePath, filepath.Ext(args.CuePath)) + "." + format
+	}
+	if err := os.WriteFile(outputPath, binary, 0644); err != nil {
+		return "", err
+	}
+	c.Global.Logf("output: %s", outputPath)
+	return outputPath, nil
+}
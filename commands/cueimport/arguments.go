@@ -0,0 +1,45 @@
+package cueimport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cue"
+)
+
+type Arguments struct {
+	Help bool `key:"--help"`
+	// json/yaml(未指定の場合は入力の拡張子)
+	Format string `key:"--format"`
+	// 出力先(未指定の場合は入力の拡張子を.cueに置き換えたパス)
+	Output string `key:"--output"`
+	// 行の順序・表記・改行コードを引き継ぐCUEシート
+	Base string `key:"--base"`
+	// CUEシートの文字コード(未指定の場合は--baseの文字コード)
+	Encoding string `key:"--encoding"`
+	// crlf/lf(未指定の場合は読み込み時の改行コード)
+	LineEnding   string `key:"--line-ending"`
+	DocumentPath string
+}
+
+func (a *Arguments) Validate() error {
+	switch a.Format {
+	case "", cue.DocumentJSON, cue.DocumentYAML:
+	default:
+		return fmt.Errorf("--format must be json or yaml (got %s)", a.Format)
+	}
+	return commands.ValidateWriteOptions(a.Encoding, a.LineEnding)
+}
+
+// NOTE: オプション以外の引数をJSON・YAMLのパスとする
+func (a *Arguments) After(values []string) error {
+	if a.Help {
+		return nil
+	}
+	if len(values) != 1 {
+		return errors.New("json or yaml file is required")
+	}
+	a.DocumentPath = values[0]
+	return nil
+}
@@ -0,0 +1,87 @@
+package cueimport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music cue-import [options] <json or yaml file>
+
+converts JSON or YAML written by cue-export (schema version 1 or 2,
+see README) back to a cue sheet
+
+options:
+  --format=<fmt>   json or yaml (default: from the input extension)
+  --output=<path>  output cue file (default: the input file with a .cue extension)
+  --base=<path>    keep the line order, spelling and line endings of this cue sheet
+                   and only rewrite the changed lines
+  --encoding=<enc>     utf-8, utf-8-bom, shift-jis, euc-jp, utf-16le, utf-16be or latin-1
+                       (default: the encoding of --base, otherwise utf-8)
+  --line-ending=<eol>  crlf or lf (default: as in --base, otherwise lf)
+  --help           show this help
+`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "cue-import"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := commandline.ArgumentsParse[Arguments](arguments)
+	if err != nil {
+		return "", commands.UsageError(err.Error(), usage)
+	}
+	if args.Help {
+		return usage, nil
+	}
+	format := args.Format
+	if format == "" {
+		var ok bool
+		format, ok = cue.DocumentFormat(args.DocumentPath)
+		if !ok {
+			return "", commands.UsageError(fmt.Sprintf("--format is required for %s", args.DocumentPath), usage)
+		}
+	}
+	args.Format = format
+	return c.Import(args)
+}
+
+// JSON・YAMLをCUEシートに変換し、出力先のパスを返す
+func (c Command) Import(args Arguments) (string, error) {
+	binary, err := os.ReadFile(args.DocumentPath)
+	if err != nil {
+		return "", err
+	}
+	cueFile, err := cue.UnmarshalDocument(binary, args.Format)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", args.DocumentPath, err)
+	}
+	if args.Base != "" {
+		// NOTE: 行の順序などを引き継ぐだけのため、音声データを読み込めない場合も警告を無視する
+		base, _, err := cue.LoadLenient(args.Base)
+		if err != nil {
+			return "", err
+		}
+		cueFile = cueFile.WithLayout(base)
+	}
+	outputPath := args.Output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(args.DocumentPath, filepath.Ext(args.DocumentPath)) + ".cue"
+	}
+	if err := cueFile.WriteCuefile(outputPath, commands.WriteOptions(args.Encoding, args.LineEnding)); err != nil {
+		return "", err
+	}
+	c.Global.Logf("output: %s", outputPath)
+	return outputPath, nil
+}
@@ -0,0 +1,24 @@
+package cuelint
+
+import (
+	"errors"
+)
+
+type Arguments struct {
+	Help bool `key:"--help"`
+	// 安全に修正できる誤りを修正してCUEシートを書き換える
+	Fix     bool `key:"--fix"`
+	CuePath string
+}
+
+// NOTE: オプション以外の引数をCUEシートのパスとする
+func (a *Arguments) After(values []string) error {
+	if a.Help {
+		return nil
+	}
+	if len(values) != 1 {
+		return errors.New("cue file is required")
+	}
+	a.CuePath = values[0]
+	return nil
+}
@@ -0,0 +1,120 @@
+package cuelint
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/go-utils/commandline"
+	"github.com/ryo-kagawa/go-utils/conditional"
+)
+
+const usage = `usage: music cue-lint [options] <cue file>
+
+checks INDEX order and times, track numbers (01 first, then in sequence),
+INDEX 01, ISRC, CATALOG, referenced files and track lengths (at least 4 seconds)
+
+options:
+  --fix   rewrite the cue sheet with safe fixes applied:
+          out-of-range MSF times are carried over, ISRC and CATALOG
+          separators are removed, and a missing file is replaced by the
+          only file with the same name and a different extension
+  --help  show this help
+`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "cue-lint"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := commandline.ArgumentsParse[Arguments](arguments)
+	if err != nil {
+		return "", commands.UsageError(err.Error(), usage)
+	}
+	if args.Help {
+		return usage, nil
+	}
+	return c.Lint(args)
+}
+
+// CUEシートを検査し、結果を返す
+// NOTE: errorの検査結果がある場合はErrorVerifyFailedを返す
+func (c Command) Lint(args Arguments) (string, error) {
+	findings, cueFile, err := c.lint(args.CuePath)
+	if err != nil {
+		return "", err
+	}
+	result := ""
+	for _, finding := range findings {
+		result += finding.String() + "\n"
+	}
+	if args.Fix && cueFile != nil {
+		fixedCue, fixed := cueFile.Fix(filepath.Dir(args.CuePath))
+		for _, finding := range fixed {
+			result += finding.String() + "\n"
+		}
+		if err := fixedCue.OutputCuefile(args.CuePath); err != nil {
+			return "", err
+		}
+		c.Global.Logf("output: %s", args.CuePath)
+		// NOTE: 修正後のCUEシートを再検査し、残った検査結果を返す
+		findings, _, err = c.lint(args.CuePath)
+		if err != nil {
+			return "", err
+		}
+		result += "\nafter fix:\n"
+		for _, finding := range findings {
+			result += finding.String() + "\n"
+		}
+	}
+	errorCount := 0
+	warningCount := 0
+	for _, finding := range findings {
+		switch finding.Severity {
+		case cue.SeverityError:
+			errorCount++
+		case cue.SeverityWarning:
+			warningCount++
+		}
+	}
+	result += fmt.Sprintf("%d errors, %d warnings\n", errorCount, warningCount)
+	if errorCount != 0 {
+		return result, errors.Join(commands.ErrorVerifyFailed, errors.New("CUEシートに誤りがあります"))
+	}
+	return result, nil
+}
+
+// CUEシートを寛容モードで読み込み、警告と内容の検査結果を返す
+// NOTE: 厳密な読み込みで失敗する警告はerror、厳密な読み込みでも受け付ける注意はwarningとする
+// NOTE: 読み込みを継続できない誤りの場合はその誤りのみを返す
+func (c Command) lint(cuePath string) ([]cue.Finding, *cue.Cue, error) {
+	cueFile, warnings, err := cue.LoadLenient(cuePath)
+	findings := []cue.Finding{}
+	for _, warning := range warnings {
+		findings = append(findings, parseFinding(warning))
+	}
+	if err != nil {
+		var parseError *cue.ParseError
+		if !errors.As(err, &parseError) {
+			return nil, nil, err
+		}
+		return append(findings, parseFinding(parseError)), nil, nil
+	}
+	return append(findings, cueFile.Lint(filepath.Dir(cuePath))...), &cueFile, nil
+}
+
+func parseFinding(err *cue.ParseError) cue.Finding {
+	return cue.Finding{
+		Severity: conditional.Value(err.Fatal, cue.SeverityError, cue.SeverityWarning),
+		Location: fmt.Sprintf("%s:%d:%d", filepath.Base(err.File), err.Line, err.Column),
+		Message:  err.Message,
+	}
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ryo-kagawa/Music/config"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+// 終了コード
+const (
+	ExitOK = 0
+	// 処理中のエラー
+	ExitError = 1
+	// 引数の誤り
+	ExitUsage = 2
+	// 照合・検証の不一致
+	ExitVerifyFailed = 3
+	// Ctrl-Cによる中断
+	ExitInterrupted = 130
+)
+
+// 照合・検証で不一致があった場合のエラー
+var ErrorVerifyFailed = errors.New("verify failed")
+
+// 引数の誤りを表すエラーに変換する
+func UsageError(message string, usage string) error {
+	return errors.Join(commandline.ErrorInvalidArgument, fmt.Errorf("%s\n\n%s", message, usage))
+}
+
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, ErrorVerifyFailed):
+		return ExitVerifyFailed
+	case errors.Is(err, commandline.ErrorInvalidArgument),
+		errors.Is(err, commandline.ErrorInvalidValidate),
+		errors.Is(err, commandline.ErrorInvalidType):
+		return ExitUsage
+	default:
+		return ExitError
+	}
+}
+
+// 全てのサブコマンドで共通の設定
+type Global struct {
+	Config config.Config
+	// 進捗や外部コマンドの出力を抑制する
+	Quiet bool
+	// 処理の詳細を出力する
+	Verbose bool
+	// 結果の出力形式(text/json)
+	Format string
+}
+
+// 進捗の出力先
+func (g Global) Progress() io.Writer {
+	if g.Quiet {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+// 処理の詳細を出力する
+func (g Global) Logf(format string, arguments ...any) {
+	if g.Verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", arguments...)
+	}
+}
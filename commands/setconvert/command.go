@@ -0,0 +1,51 @@
+package setconvert
+
+import (
+	"fmt"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/commands/convertflac"
+	"github.com/ryo-kagawa/Music/types/albumset"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music set-convert <set file>
+
+encodes the WAVE files of every disc split by set-split to FLAC`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "set-convert"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	if len(arguments) != 1 {
+		return "", commands.UsageError("set file is required", usage)
+	}
+	if err := c.Convert(arguments[0]); err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+// set-splitで分割した全てのディスクのWAVEファイルをFLACに変換する
+func (c Command) Convert(setPath string) error {
+	set, err := albumset.Load(setPath)
+	if err != nil {
+		return err
+	}
+	converter := convertflac.Command{Global: c.Global}
+	for number := 1; number <= set.TotalDiscs(); number++ {
+		fmt.Fprintf(c.Global.Progress(), "Disc %d/%d\n", number, set.TotalDiscs())
+		if err := converter.Convert(set.SplitCuePath(number)); err != nil {
+			return fmt.Errorf("Disc %d: %w", number, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package setsplit
+
+import (
+	"errors"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/commands/wavesplitcue"
+)
+
+type Arguments struct {
+	Help       bool `key:"--help"`
+	Deemphasis bool `key:"--deemphasis"`
+	// 未対応の行を警告として読み飛ばす
+	Lenient bool `key:"--lenient"`
+	// CUEシートの文字コード(未指定の場合は読み込み時の文字コード)
+	Encoding string `key:"--encoding"`
+	// crlf/lf(未指定の場合は読み込み時の改行コード)
+	LineEnding string `key:"--line-ending"`
+	SetPath    string
+}
+
+func (a *Arguments) Validate() error {
+	return commands.ValidateWriteOptions(a.Encoding, a.LineEnding)
+}
+
+// NOTE: オプション以外の引数を定義ファイルのパスとする
+func (a *Arguments) After(values []string) error {
+	if a.Help {
+		return nil
+	}
+	if len(values) != 1 {
+		return errors.New("set file is required")
+	}
+	a.SetPath = values[0]
+	return nil
+}
+
+// ディスク毎の分割に使用する引数
+func (a Arguments) splitArguments() wavesplitcue.Arguments {
+	return wavesplitcue.Arguments{
+		Deemphasis: a.Deemphasis,
+		Lenient:    a.Lenient,
+		Encoding:   a.Encoding,
+		LineEnding: a.LineEnding,
+	}
+}
@@ -0,0 +1,84 @@
+package setsplit
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/commands/wavesplitcue"
+	"github.com/ryo-kagawa/Music/types/albumset"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music set-split [options] <set file>
+
+splits every disc of a multi-disc set (see README) into
+<album title>/Disc 1/, <album title>/Disc 2/, ... next to the set file.
+The shared album fields, disc number, total discs and disc subtitle of the
+set are written to each split cue sheet.
+
+options:
+  --deemphasis         apply de-emphasis to tracks with the PRE flag
+  --lenient            skip unsupported lines with a warning instead of failing
+  --encoding=<enc>     encoding of the output cue sheets: utf-8, utf-8-bom, shift-jis,
+                       euc-jp, utf-16le, utf-16be or latin-1
+                       (default: the encoding of each input cue sheet)
+  --line-ending=<eol>  crlf or lf
+  --help               show this help
+`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "set-split"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := commandline.ArgumentsParse[Arguments](arguments)
+	if err != nil {
+		return "", commands.UsageError(err.Error(), usage)
+	}
+	if args.Help {
+		return usage, nil
+	}
+	return c.Split(args)
+}
+
+// 全てのディスクをトラック毎に分割し、分割後のCUEシートのパスを1行ずつ返す
+// NOTE: 番号の不一致などで途中のディスクから失敗しないよう、先に全てのCUEシートを読み込む
+func (c Command) Split(args Arguments) (string, error) {
+	set, err := albumset.Load(args.SetPath)
+	if err != nil {
+		return "", err
+	}
+	splitter := wavesplitcue.Command{Global: c.Global}
+	cueFiles := make([]cue.Cue, set.TotalDiscs())
+	for i := range cueFiles {
+		number := i + 1
+		cueFile, err := c.Global.LoadCue(set.CuePath(number), args.Lenient)
+		if err != nil {
+			return "", err
+		}
+		cueFiles[i], err = set.Apply(number, cueFile)
+		if err != nil {
+			return "", err
+		}
+	}
+	outputPaths := []string{}
+	for i, cueFile := range cueFiles {
+		number := i + 1
+		fmt.Fprintf(c.Global.Progress(), "Disc %d/%d\n", number, set.TotalDiscs())
+		outputPath := set.SplitCuePath(number)
+		if err := splitter.SplitCue(cueFile, filepath.Dir(set.CuePath(number)), outputPath, args.splitArguments()); err != nil {
+			return "", fmt.Errorf("Disc %d: %w", number, err)
+		}
+		outputPaths = append(outputPaths, outputPath)
+	}
+	return strings.Join(outputPaths, "\n"), nil
+}
@@ -0,0 +1,232 @@
+package verifylog
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/config"
+	"github.com/ryo-kagawa/Music/types/riplog"
+	"github.com/ryo-kagawa/Music/utils"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music verify-log <log file>
+
+The signature or checksum of a cd-rip log (and of the JSON log next to it)
+is verified with logKey in config.json.`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "verify-log"
+}
+
+// ログと同じディレクトリにある音声ファイルのCRCを再計算し、ログに記録されたCRCと照合する
+func (c Command) Execute(arguments []string) (string, error) {
+	if len(arguments) != 1 {
+		return "", commands.UsageError("log file is required", usage)
+	}
+	logPath := arguments[0]
+	log, err := riplog.Load(logPath)
+	if err != nil {
+		return "", err
+	}
+	audioFiles, err := findAudioFiles(filepath.Dir(logPath))
+	if err != nil {
+		return "", err
+	}
+	integrity, tampered, err := c.verifyIntegrity(logPath)
+	if err != nil {
+		return "", err
+	}
+	result, err := c.Verify(log, audioFiles, nil)
+	result += integrity
+	if tampered {
+		return result, errors.Join(err, commands.ErrorVerifyFailed, riplog.ErrorIntegrityMismatch)
+	}
+	return result, err
+}
+
+// ログと同じ名前のJSON形式のログがあれば合わせて、署名・チェックサムを検証する
+// NOTE: 署名・チェックサムが無い場合や鍵が無く署名を検証できない場合は警告とし、一致しない場合のみ照合の失敗とする
+func (c Command) verifyIntegrity(logPath string) (string, bool, error) {
+	key := []byte(c.Global.Config.LogKey)
+	text, err := utils.ReadTextFileToUTF8(logPath)
+	if err != nil {
+		return "", false, err
+	}
+	result := ""
+	tampered := false
+	report := func(path string, method string, err error) {
+		name := filepath.Base(path)
+		switch {
+		case errors.Is(err, riplog.ErrorIntegrityMismatch):
+			result += fmt.Sprintf("Log       NG  %s  %v\n", name, err)
+			tampered = true
+		case err != nil:
+			fmt.Fprintf(c.Global.Progress(), "warning: %s: %v\n", name, err)
+			result += fmt.Sprintf("Log       --  %s  not verified\n", name)
+		default:
+			result += fmt.Sprintf("Log       OK  %s  %s\n", name, method)
+		}
+	}
+	method, err := riplog.VerifyText(text, key)
+	report(logPath, method, err)
+	jsonPath := strings.TrimSuffix(logPath, filepath.Ext(logPath)) + ".json"
+	if jsonPath != logPath {
+		value, err := os.ReadFile(jsonPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return "", false, err
+		default:
+			method, err := riplog.VerifyJSON(value, key)
+			report(jsonPath, method, err)
+		}
+	}
+	return result, tampered, nil
+}
+
+// 音声ファイルのCRCを計算し、ログに記録されたCRCと照合する
+// NOTE: ディエンファシスを適用したトラックは読み込んだ内容と異なるため照合しない
+func (c Command) Verify(log riplog.Log, audioFiles []string, deemphasized []int) (string, error) {
+	result := fmt.Sprintf("%s\n", log.Application)
+	mismatch := false
+	for i, track := range log.Tracks {
+		expected := track.CopyCRC
+		if expected == "" {
+			expected = track.TestCRC
+		}
+		if slices.Contains(deemphasized, track.Number) {
+			result += fmt.Sprintf("Track %02d  --  %s  de-emphasized, not verified\n", track.Number, expected)
+			continue
+		}
+		source, ok := findSource(log, i, audioFiles)
+		if !ok {
+			result += fmt.Sprintf("Track %02d  --  %s  audio file not found\n", track.Number, expected)
+			mismatch = true
+			continue
+		}
+		name := filepath.Base(source.path)
+		// NOTE: 順序で対応させた場合は異なるファイルと照合している可能性があるため警告する
+		if source.byOrder {
+			fmt.Fprintf(c.Global.Progress(), "warning: Track %02d: no audio file matches the log file name, paired by order with %s\n", track.Number, name)
+			name += " (paired by order)"
+		}
+		actual, err := calculateCRC(c.Global.Config, source)
+		if err != nil {
+			return "", err
+		}
+		if actual == expected {
+			result += fmt.Sprintf("Track %02d  OK  %s  %s\n", track.Number, actual, name)
+		} else {
+			result += fmt.Sprintf("Track %02d  NG  %s  log: %s  %s\n", track.Number, actual, expected, name)
+			mismatch = true
+		}
+	}
+	if mismatch {
+		return result, errors.Join(commands.ErrorVerifyFailed, errors.New("ログと一致しないトラックがあります"))
+	}
+
+	return result, nil
+}
+
+func findAudioFiles(directory string) ([]string, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".wav", ".flac":
+			result = append(result, filepath.Join(directory, entry.Name()))
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+// CRCを計算する範囲
+type source struct {
+	path string
+	// 音声データ先頭からの位置と長さ(Byte)、lengthが負の場合は末尾まで
+	offset int64
+	length int64
+	// ファイル名が一致せず、ファイルの順序で対応させた
+	byOrder bool
+}
+
+func stem(path string) string {
+	// NOTE: ログにはリッピングした環境のパスが記録されているため、区切り文字は「\」「/」の両方を考慮する
+	name := path[strings.LastIndexAny(path, "\\/")+1:]
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+func findSource(log riplog.Log, trackIndex int, audioFiles []string) (source, bool) {
+	track := log.Tracks[trackIndex]
+	if track.Filename != "" {
+		for _, audioFile := range audioFiles {
+			if stem(audioFile) == stem(track.Filename) {
+				return source{path: audioFile, length: -1}, true
+			}
+		}
+	}
+	// NOTE: イメージとして読み込んだログは全体を1つのファイルと照合する
+	if len(audioFiles) == 1 && track.Number == 0 {
+		return source{path: audioFiles[0], length: -1}, true
+	}
+	// NOTE: ファイル名が一致しない場合は、ファイル数とトラック数が一致し、他のトラックの名前と一致しないファイルのみ順序で対応させる
+	if len(audioFiles) == len(log.Tracks) {
+		audioFile := audioFiles[trackIndex]
+		if !slices.ContainsFunc(log.Tracks, func(t riplog.Track) bool {
+			return t.Filename != "" && stem(t.Filename) == stem(audioFile)
+		}) {
+			return source{path: audioFile, length: -1, byOrder: true}, true
+		}
+		return source{}, false
+	}
+	if len(audioFiles) == 1 {
+		// NOTE: イメージファイルの場合はTOCの位置で切り出す
+		for _, entry := range log.TOC {
+			if entry.Track == track.Number {
+				return source{
+					path:   audioFiles[0],
+					offset: int64(entry.StartLBA) * 2352,
+					length: int64(entry.EndLBA-entry.StartLBA) * 2352,
+				}, true
+			}
+		}
+	}
+	return source{}, false
+}
+
+func calculateCRC(config config.Config, s source) (string, error) {
+	reader, closer, err := openPCM(config, s.path)
+	if err != nil {
+		return "", err
+	}
+	defer closer()
+	if _, err := io.CopyN(io.Discard, reader, s.offset); err != nil {
+		return "", err
+	}
+	if 0 <= s.length {
+		reader = io.LimitReader(reader, s.length)
+	}
+	crc := crc32.NewIEEE()
+	if _, err := io.Copy(crc, reader); err != nil {
+		return "", err
+	}
+	return riplog.FormatCRC(crc.Sum32()), nil
+}
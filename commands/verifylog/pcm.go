@@ -0,0 +1,65 @@
+package verifylog
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/config"
+	"github.com/ryo-kagawa/Music/types/wave"
+)
+
+// 音声ファイルのPCMデータを読み込む
+func openPCM(config config.Config, path string) (io.Reader, func() error, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac":
+		cmd := exec.Command(
+			config.FlacExePath,
+			"--decode",
+			"--stdout",
+			"--silent",
+			"--force-raw-format",
+			"--endian=little",
+			"--sign=signed",
+			path,
+		)
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, err
+		}
+		return stdout, func() error {
+			io.Copy(io.Discard, stdout)
+			return cmd.Wait()
+		}, nil
+	default:
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader, err := waveData(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return reader, file.Close, nil
+	}
+}
+
+// WAVEファイルのdataチャンクを返す
+func waveData(file *os.File) (io.Reader, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	waveInfo, err := wave.Read(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return waveInfo.Data(file), nil
+}
@@ -0,0 +1,35 @@
+package wavesplitcue
+
+import (
+	"errors"
+
+	"github.com/ryo-kagawa/Music/commands"
+)
+
+type Arguments struct {
+	Help       bool `key:"--help"`
+	Deemphasis bool `key:"--deemphasis"`
+	// 未対応の行を警告として読み飛ばす
+	Lenient bool `key:"--lenient"`
+	// CUEシートの文字コード(未指定の場合は読み込み時の文字コード)
+	Encoding string `key:"--encoding"`
+	// crlf/lf(未指定の場合は読み込み時の改行コード)
+	LineEnding string `key:"--line-ending"`
+	CuePath    string
+}
+
+func (a *Arguments) Validate() error {
+	return commands.ValidateWriteOptions(a.Encoding, a.LineEnding)
+}
+
+// NOTE: オプション以外の引数をCUEシートのパスとする
+func (a *Arguments) After(values []string) error {
+	if a.Help {
+		return nil
+	}
+	if len(values) != 1 {
+		return errors.New("cue file is required")
+	}
+	a.CuePath = values[0]
+	return nil
+}
@@ -0,0 +1,131 @@
+package wavesplitcue
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/ryo-kagawa/Music/commands"
+	"github.com/ryo-kagawa/Music/types/cue"
+	"github.com/ryo-kagawa/go-utils/commandline"
+)
+
+const usage = `usage: music wave-split-cue [options] <cue file>
+
+FILE types WAVE, AIFF, BINARY and MOTOROLA are read directly.
+FLAC files are decoded with flac (flacExePath in config.json).
+MP3 files are not supported.
+
+options:
+  --deemphasis         apply de-emphasis to tracks with the PRE flag,
+                       writing them as 24-bit WAVE files and clearing the flag
+  --lenient            skip unsupported lines with a warning instead of failing
+  --encoding=<enc>     encoding of the output cue sheet: utf-8, utf-8-bom, shift-jis,
+                       euc-jp, utf-16le, utf-16be or latin-1
+                       (default: the encoding of the input cue sheet)
+  --line-ending=<eol>  crlf or lf (default: as in the input cue sheet)
+  --help               show this help
+`
+
+type Command struct {
+	Global commands.Global
+}
+
+var _ = (commandline.SubCommand)(Command{})
+
+func (Command) Name() string {
+	return "wave-split-cue"
+}
+
+func (c Command) Execute(arguments []string) (string, error) {
+	args, err := commandline.ArgumentsParse[Arguments](arguments)
+	if err != nil {
+		return "", commands.UsageError(err.Error(), usage)
+	}
+	if args.Help {
+		return usage, nil
+	}
+	return c.Split(args)
+}
+
+// CUEシートに従ってトラック毎に分割し、分割後のCUEシートのパスを返す
+func (c Command) Split(args Arguments) (string, error) {
+	cuePath := args.CuePath
+	cueFile, err := c.Global.LoadCue(cuePath, args.Lenient)
+	if err != nil {
+		return "", err
+	}
+	outputDirectory := filepath.Join(filepath.Dir(cuePath), cue.TitleToFileName(cueFile.Album.Field.Title))
+	outputPath := filepath.Join(outputDirectory, fmt.Sprintf("%s.cue", cue.TitleToFileName(cueFile.Album.Field.Title)))
+	if err := c.SplitCue(cueFile, filepath.Dir(cuePath), outputPath, args); err != nil {
+		return "", err
+	}
+
+	return outputPath, nil
+}
+
+// 読み込んだCUEシートに従ってトラック毎に分割し、分割後のCUEシートと同じディレクトリに出力する
+// NOTE: 複数枚組のアルバムではディスク毎に出力先を指定して使用する
+func (c Command) SplitCue(cueFile cue.Cue, cueDirectory string, outputPath string, args Arguments) error {
+	// NOTE: デコードしたWAVEファイルは出力が終わるまで使用する
+	tempDirectory, err := os.MkdirTemp("", "music-wave-split-cue-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDirectory)
+	cueFile, err = c.decode(cueFile, cueDirectory, tempDirectory)
+	if err != nil {
+		return err
+	}
+	if err := cueFile.RequireAudio(); err != nil {
+		return err
+	}
+	cueFile = cueFile.SplitTrack()
+	// NOTE: PREフラグが設定されたトラックにディエンファシスを適用する
+	if args.Deemphasis {
+		cueFile = cueFile.Deemphasis()
+	}
+	outputDirectory := filepath.Dir(outputPath)
+	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
+		return err
+	}
+	c.Global.Logf("output: %s", outputDirectory)
+	if err := cueFile.OutputWave(outputDirectory); err != nil {
+		return err
+	}
+	return cueFile.WriteCuefile(outputPath, commands.WriteOptions(args.Encoding, args.LineEnding))
+}
+
+// FLACファイルを一時ディレクトリにWAVEファイルとしてデコードする
+func (c Command) decode(cueFile cue.Cue, cueDirectory string, tempDirectory string) (cue.Cue, error) {
+	files := slices.Clone(cueFile.Album.Command.Files)
+	for i, file := range files {
+		if file.Type != cue.FileTypeFlac {
+			continue
+		}
+		wavePath := filepath.Join(tempDirectory, fmt.Sprintf("%02d.wav", i+1))
+		cmd := exec.Command(
+			c.Global.Config.FlacExePath,
+			"--decode",
+			"--silent",
+			"--force",
+			"--output-name="+wavePath,
+			filepath.Join(cueDirectory, file.Name),
+		)
+		cmd.Stdout = c.Global.Progress()
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return cue.Cue{}, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		c.Global.Logf("decode: %s", file.Name)
+		decoded, err := file.WithDecodedWave(wavePath)
+		if err != nil {
+			return cue.Cue{}, err
+		}
+		files[i] = decoded
+	}
+	cueFile.Album.Command.Files = files
+	return cueFile, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const fileName = "config.json"
+
+type Config struct {
+	FlacExePath     string `json:"flacExePath"`
+	MetaflacExePath string `json:"metaflacExePath"`
+	CdRip           struct {
+		Drive    string `json:"drive"`
+		Verify   *int   `json:"verify"`
+		Offset   *int   `json:"offset"`
+		Output   string `json:"output"`
+		ReadMode string `json:"readMode"`
+		// 読み込み速度(等速に対する倍率、0の場合は最大速度)
+		Speed   *int `json:"speed"`
+		Retries *int `json:"retries"`
+		// 再試行までの待ち時間(ミリ秒)
+		RetryBackoff *int   `json:"retryBackoff"`
+		RetrySpeed   *int   `json:"retrySpeed"`
+		OnError      string `json:"onError"`
+	} `json:"cdRip"`
+	// cd-ripのログの署名に使用する秘密の鍵(空の場合は署名しない)
+	LogKey string `json:"logKey"`
+	// CUEシートのREMに記録する担当の種類の追加
+	Roles []struct {
+		// REMのキー
+		Key string `json:"key"`
+		// Vorbisコメントのタグ名(省略時はキー)
+		Tag string `json:"tag"`
+		// 演奏者の場合の楽器名
+		Instrument string `json:"instrument"`
+	} `json:"roles"`
+}
+
+// 実行ファイルと同じディレクトリの設定ファイル
+func DefaultPath() (string, error) {
+	exeFilePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(exeFilePath), fileName), nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		FlacExePath:     "flac",
+		MetaflacExePath: "metaflac",
+	}
+}
+
+// NOTE: 設定ファイルが存在しない場合は既定値を使用する
+func Load(configPath string) (Config, error) {
+	binary, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := defaultConfig()
+	if err := json.Unmarshal([]byte(binary), &config); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
@@ -7,3 +7,5 @@ require (
 	golang.org/x/sys v0.46.0
 	golang.org/x/text v0.38.0
 )
+
+require gopkg.in/yaml.v3 v3.0.1
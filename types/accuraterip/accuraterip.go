@@ -0,0 +1,232 @@
+package accuraterip
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// 1セクタあたりのサンプル数
+const samplesPerSector = 588
+
+// 先頭トラックの先頭と最終トラックの末尾で計算から除外するセクタ数
+const skipSectors = 5
+
+type Checksum struct {
+	V1 uint32
+	V2 uint32
+}
+
+// 1トラック分のAccurateRipチェックサムを計算する
+// NOTE: 16bitステレオのサンプルを順に書き込む
+type Hasher struct {
+	// 計算対象とするサンプル番号の範囲(1始まり)
+	checkFrom uint32
+	checkTo   uint32
+	mult      uint32
+	checksum  Checksum
+	remainder []byte
+}
+
+func NewHasher(sampleCount int, first bool, last bool) *Hasher {
+	h := &Hasher{
+		checkFrom: 1,
+		checkTo:   uint32(sampleCount),
+		mult:      1,
+		remainder: make([]byte, 0, 4),
+	}
+	if first {
+		h.checkFrom = samplesPerSector * skipSectors
+	}
+	if last {
+		h.checkTo = uint32(sampleCount) - samplesPerSector*skipSectors
+	}
+	return h
+}
+
+func (h *Hasher) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(h.remainder) != 0 {
+		length := min(4-len(h.remainder), len(p))
+		h.remainder = append(h.remainder, p[:length]...)
+		p = p[length:]
+		if len(h.remainder) < 4 {
+			return n, nil
+		}
+		h.add(binary.LittleEndian.Uint32(h.remainder))
+		h.remainder = h.remainder[:0]
+	}
+	for ; 4 <= len(p); p = p[4:] {
+		h.add(binary.LittleEndian.Uint32(p))
+	}
+	h.remainder = append(h.remainder, p...)
+	return n, nil
+}
+
+func (h *Hasher) add(sample uint32) {
+	if h.checkFrom <= h.mult && h.mult <= h.checkTo {
+		h.checksum.V1 += sample * h.mult
+		product := uint64(sample) * uint64(h.mult)
+		h.checksum.V2 += uint32(product>>32) + uint32(product)
+	}
+	h.mult++
+}
+
+func (h *Hasher) Sum() Checksum {
+	return h.checksum
+}
+
+type DiscID struct {
+	TrackCount int
+	ID1        uint32
+	ID2        uint32
+	CDDB       uint32
+}
+
+// trackLBAsは各トラックの開始位置(トラック01の開始位置を0とする)
+func NewDiscID(trackLBAs []int, leadOutLBA int) DiscID {
+	id := DiscID{
+		TrackCount: len(trackLBAs),
+	}
+	cddbSum := 0
+	for i, lba := range append(slices.Clone(trackLBAs), leadOutLBA) {
+		id.ID1 += uint32(lba)
+		id.ID2 += uint32(max(lba, 1) * (i + 1))
+		if i < len(trackLBAs) {
+			for seconds := (lba + 150) / 75; 0 < seconds; seconds /= 10 {
+				cddbSum += seconds % 10
+			}
+		}
+	}
+	length := 0
+	if len(trackLBAs) != 0 {
+		length = (leadOutLBA+150)/75 - (trackLBAs[0]+150)/75
+	}
+	id.CDDB = uint32(cddbSum%0xFF)<<24 | uint32(length)<<8 | uint32(len(trackLBAs))
+	return id
+}
+
+func (d DiscID) URL() string {
+	return fmt.Sprintf(
+		"http://www.accuraterip.com/accuraterip/%x/%x/%x/dBAR-%03d-%08x-%08x-%08x.bin",
+		d.ID1&0xF,
+		d.ID1>>4&0xF,
+		d.ID1>>8&0xF,
+		d.TrackCount,
+		d.ID1,
+		d.ID2,
+		d.CDDB,
+	)
+}
+
+type TrackEntry struct {
+	Confidence int
+	CRC        uint32
+	Frame450   uint32
+}
+
+// プレス毎の登録内容
+type Entry struct {
+	Tracks []TrackEntry
+}
+
+var ErrorNotFound = errors.New("AccurateRipデータベースに登録されていません")
+
+func Lookup(ctx context.Context, id DiscID) ([]Entry, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, id.URL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrorNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AccurateRipデータベースの取得に失敗しました: %s", response.Status)
+	}
+	value, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntries(value)
+}
+
+func parseEntries(value []byte) ([]Entry, error) {
+	entries := []Entry{}
+	for 0 < len(value) {
+		// 1: トラック数
+		// 4: ID1
+		// 4: ID2
+		// 4: CDDB
+		if len(value) < 13 {
+			return nil, errors.New("AccurateRipデータベースの形式が不正です")
+		}
+		trackCount := int(value[0])
+		value = value[13:]
+		if len(value) < trackCount*9 {
+			return nil, errors.New("AccurateRipデータベースの形式が不正です")
+		}
+		entry := Entry{}
+		for range trackCount {
+			entry.Tracks = append(
+				entry.Tracks,
+				TrackEntry{
+					Confidence: int(value[0]),
+					CRC:        binary.LittleEndian.Uint32(value[1:5]),
+					Frame450:   binary.LittleEndian.Uint32(value[5:9]),
+				},
+			)
+			value = value[9:]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type Result struct {
+	Checksum Checksum
+	// 一致したチェックサムの種類(v1/v2)、一致しない場合は空文字
+	Version    string
+	Confidence int
+	// データベースに登録されている最大の信頼度
+	Total int
+}
+
+func (r Result) Matched() bool {
+	return r.Version != ""
+}
+
+// trackIndexは0始まりのトラック位置
+func Match(entries []Entry, trackIndex int, checksum Checksum) Result {
+	result := Result{
+		Checksum: checksum,
+	}
+	for _, entry := range entries {
+		if len(entry.Tracks) <= trackIndex {
+			continue
+		}
+		track := entry.Tracks[trackIndex]
+		result.Total = max(result.Total, track.Confidence)
+		switch track.CRC {
+		case checksum.V2:
+			if result.Confidence < track.Confidence {
+				result.Version = "v2"
+				result.Confidence = track.Confidence
+			}
+		case checksum.V1:
+			if result.Confidence < track.Confidence {
+				result.Version = "v1"
+				result.Confidence = track.Confidence
+			}
+		}
+	}
+	return result
+}
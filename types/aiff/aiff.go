@@ -0,0 +1,175 @@
+package aiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ryo-kagawa/Music/types/wave"
+)
+
+// AIFFファイルの形式の誤り
+type FormatError struct {
+	// 誤りを検出したファイル先頭からの位置
+	Offset  int64
+	Message string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("AIFFフォーマットエラー(offset %d): %s", e.Offset, e.Message)
+}
+
+func formatError(offset int64, format string, arguments ...any) error {
+	return &FormatError{
+		Offset:  offset,
+		Message: fmt.Sprintf(format, arguments...),
+	}
+}
+
+type Aiff struct {
+	// WAVEファイルとして出力する場合の形式
+	Format wave.Format
+	// SSNDチャンクのサンプルデータの位置とサイズ
+	DataOffset int64
+	DataSize   int64
+	// サンプルがビッグエンディアンで格納されている
+	// NOTE: AIFF-Cのsowtのみリトルエンディアン
+	BigEndian bool
+}
+
+// AIFF・AIFF-Cファイルのヘッダーを読み込む
+// NOTE: 非圧縮のリニアPCM(9bit以上)のみ対応する
+func Read(reader io.ReaderAt, size int64) (Aiff, error) {
+	header := make([]byte, 12)
+	if _, err := reader.ReadAt(header, 0); err != nil {
+		return Aiff{}, formatError(0, "ヘッダーを読み込めません: %v", err)
+	}
+	if string(header[0:4]) != "FORM" {
+		return Aiff{}, formatError(0, "FORMではありません(%q)", header[0:4])
+	}
+	formType := string(header[8:12])
+	if formType != "AIFF" && formType != "AIFC" {
+		return Aiff{}, formatError(8, "AIFF・AIFF-Cではありません(%q)", formType)
+	}
+	result := Aiff{
+		BigEndian: true,
+	}
+	foundCommon := false
+	foundSound := false
+	var sampleFrames uint32
+	offset := int64(12)
+	for offset+8 <= size {
+		chunkHeader := make([]byte, 8)
+		if _, err := reader.ReadAt(chunkHeader, offset); err != nil {
+			return Aiff{}, formatError(offset, "チャンクのヘッダーを読み込めません: %v", err)
+		}
+		id := string(chunkHeader[0:4])
+		chunkSize := int64(binary.BigEndian.Uint32(chunkHeader[4:8]))
+		dataOffset := offset + 8
+		if size < dataOffset+chunkSize {
+			return Aiff{}, formatError(offset, "%sチャンクがファイルの末尾を超えています", id)
+		}
+		switch id {
+		case "COMM":
+			value := make([]byte, chunkSize)
+			if _, err := reader.ReadAt(value, dataOffset); err != nil {
+				return Aiff{}, formatError(dataOffset, "COMMチャンクを読み込めません: %v", err)
+			}
+			format, frames, bigEndian, err := parseCommon(value, formType, dataOffset)
+			if err != nil {
+				return Aiff{}, err
+			}
+			result.Format = format
+			result.BigEndian = bigEndian
+			sampleFrames = frames
+			foundCommon = true
+		case "SSND":
+			// 0-3: offset
+			// 4-7: blockSize
+			if chunkSize < 8 {
+				return Aiff{}, formatError(offset, "SSNDチャンクが短すぎます(%dByte)", chunkSize)
+			}
+			value := make([]byte, 4)
+			if _, err := reader.ReadAt(value, dataOffset); err != nil {
+				return Aiff{}, formatError(dataOffset, "SSNDチャンクを読み込めません: %v", err)
+			}
+			soundOffset := int64(binary.BigEndian.Uint32(value))
+			if chunkSize < 8+soundOffset {
+				return Aiff{}, formatError(dataOffset, "SSNDチャンクのoffsetが不正です(%d)", soundOffset)
+			}
+			result.DataOffset = dataOffset + 8 + soundOffset
+			result.DataSize = chunkSize - 8 - soundOffset
+			foundSound = true
+		}
+		// NOTE: チャンクは2Byte境界に配置される
+		offset = dataOffset + chunkSize + chunkSize%2
+	}
+	if !foundCommon {
+		return Aiff{}, formatError(offset, "COMMチャンクがありません")
+	}
+	if !foundSound {
+		return Aiff{}, formatError(offset, "SSNDチャンクがありません")
+	}
+	// NOTE: SSNDチャンクにはブロック単位の余りが含まれる場合があるため、COMMチャンクのサンプルフレーム数を優先する
+	result.DataSize = min(result.DataSize, int64(sampleFrames)*int64(result.Format.BlockAlign))
+	return result, nil
+}
+
+// COMMチャンクを読み込む
+// 0-1: numChannels
+// 2-5: numSampleFrames
+// 6-7: sampleSize
+// 8-17: sampleRate(80bit拡張精度浮動小数点)
+// 18-21: compressionType(AIFF-Cのみ)
+func parseCommon(value []byte, formType string, offset int64) (wave.Format, uint32, bool, error) {
+	if len(value) < 18 || (formType == "AIFC" && len(value) < 22) {
+		return wave.Format{}, 0, false, formatError(offset, "COMMチャンクが短すぎます(%dByte)", len(value))
+	}
+	channels := binary.BigEndian.Uint16(value[0:2])
+	sampleFrames := binary.BigEndian.Uint32(value[2:6])
+	sampleSize := binary.BigEndian.Uint16(value[6:8])
+	samplingRate := extendedToUint32(value[8:18])
+	bigEndian := true
+	if formType == "AIFC" {
+		switch compressionType := string(value[18:22]); compressionType {
+		case "NONE", "twos":
+		case "sowt":
+			bigEndian = false
+		default:
+			return wave.Format{}, 0, false, formatError(offset+18, "圧縮形式%qに未対応です", compressionType)
+		}
+	}
+	if channels == 0 {
+		return wave.Format{}, 0, false, formatError(offset, "チャンネル数が0です")
+	}
+	// NOTE: 8bitはAIFFでは符号付き、WAVEでは符号なしのため未対応とする
+	if sampleSize <= 8 || 32 < sampleSize {
+		return wave.Format{}, 0, false, formatError(offset+6, "ビット深度に未対応です(%dbit)", sampleSize)
+	}
+	if samplingRate == 0 {
+		return wave.Format{}, 0, false, formatError(offset+8, "サンプリングレートが0です")
+	}
+	// NOTE: サンプルは1Byte単位に切り上げて格納される
+	bitDepth := (sampleSize + 7) / 8 * 8
+	format := wave.NewPCM(channels, samplingRate, bitDepth)
+	if sampleSize != bitDepth {
+		format.Extensible = true
+		format.ValidBits = sampleSize
+	}
+	return format, sampleFrames, bigEndian, nil
+}
+
+// 80bit拡張精度浮動小数点を整数に変換する
+func extendedToUint32(value []byte) uint32 {
+	exponent := int(binary.BigEndian.Uint16(value[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(value[2:10])
+	if value[0]&0x80 != 0 || exponent == 0 {
+		return 0
+	}
+	rate := math.Ldexp(float64(mantissa), exponent-16383-63)
+	if math.MaxUint32 < rate {
+		return 0
+	}
+	return uint32(math.Round(rate))
+}
@@ -0,0 +1,155 @@
+package albumset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ryo-kagawa/Music/types/cue"
+)
+
+// 複数枚組のアルバム
+type Set struct {
+	// 定義ファイルのパス
+	Path string `json:"-"`
+	// 全てのディスクで共通のアルバムの情報
+	// NOTE: 空の項目は各ディスクのCUEシートの値を使用する
+	Album Album `json:"album"`
+	// ディスクの一覧(1枚目から順に並べる)
+	Discs []Disc `json:"discs"`
+}
+
+type Album struct {
+	// NOTE: 出力先のディレクトリ名に使用するため必須とする
+	Title     string `json:"title"`
+	Performer string `json:"performer"`
+	Genre     string `json:"genre"`
+	Date      string `json:"date"`
+	// 販売元
+	Publisher string `json:"publisher"`
+	Label     string `json:"label"`
+	Composer  string `json:"composer"`
+	Comment   string `json:"comment"`
+}
+
+type Disc struct {
+	// CUEシートのパス(定義ファイルのディレクトリからの相対パス)
+	Cue string `json:"cue"`
+	// ディスクの副題
+	Subtitle string `json:"subtitle"`
+}
+
+// 定義ファイルを読み込む
+// NOTE: 未知の項目がある場合は読み込みを中断する
+func Load(path string) (Set, error) {
+	binary, err := os.ReadFile(path)
+	if err != nil {
+		return Set{}, err
+	}
+	set := Set{}
+	decoder := json.NewDecoder(bytes.NewReader(binary))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&set); err != nil {
+		return Set{}, fmt.Errorf("%s: %w", path, err)
+	}
+	set.Path = path
+	if err := set.validate(); err != nil {
+		return Set{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return set, nil
+}
+
+func (s Set) validate() error {
+	if s.Album.Title == "" {
+		return errors.New("album.titleがありません")
+	}
+	if len(s.Discs) == 0 {
+		return errors.New("discsがありません")
+	}
+	cuePaths := map[string]int{}
+	for i := range s.Discs {
+		number := i + 1
+		if s.Discs[i].Cue == "" {
+			return fmt.Errorf("%d枚目のcueがありません", number)
+		}
+		cuePath := filepath.Clean(s.CuePath(number))
+		if previous, ok := cuePaths[cuePath]; ok {
+			return fmt.Errorf("%d枚目のCUEシートが%d枚目と同じです", number, previous)
+		}
+		cuePaths[cuePath] = number
+	}
+	return nil
+}
+
+// ディスクの枚数
+func (s Set) TotalDiscs() int {
+	return len(s.Discs)
+}
+
+// 指定したディスク(1から)のCUEシートのパス
+func (s Set) CuePath(number int) string {
+	cuePath := s.Discs[number-1].Cue
+	if filepath.IsAbs(cuePath) {
+		return cuePath
+	}
+	return filepath.Join(filepath.Dir(s.Path), cuePath)
+}
+
+// 分割後のファイルの出力先
+// NOTE: 定義ファイルのディレクトリにアルバム名のディレクトリを作成する
+func (s Set) OutputDirectory() string {
+	return filepath.Join(filepath.Dir(s.Path), cue.TitleToFileName(s.Album.Title))
+}
+
+// 指定したディスクの分割後のファイルの出力先(「Disc 1」など)
+func (s Set) DiscDirectory(number int) string {
+	return filepath.Join(s.OutputDirectory(), fmt.Sprintf("Disc %d", number))
+}
+
+// 指定したディスクの分割後のCUEシートのパス
+func (s Set) SplitCuePath(number int) string {
+	return filepath.Join(s.DiscDirectory(number), cue.TitleToFileName(s.Album.Title)+".cue")
+}
+
+// 共通のアルバムの情報とディスクの番号・副題をCUEシートに設定する
+// NOTE: CUEシートのDISCNUMBER・TOTALDISCSが組の中の位置と一致しない場合はエラーとする
+func (s Set) Apply(number int, cueFile cue.Cue) (cue.Cue, error) {
+	rem := &cueFile.Album.Field.Rem
+	discNumber := strconv.Itoa(number)
+	totalDiscs := strconv.Itoa(s.TotalDiscs())
+	if rem.DiscNumber != "" && rem.DiscNumber != discNumber {
+		return cue.Cue{}, fmt.Errorf("%s: DISCNUMBER %sが組の%d枚目と一致しません", s.CuePath(number), rem.DiscNumber, number)
+	}
+	if rem.TotalDiscs != "" && rem.TotalDiscs != totalDiscs {
+		return cue.Cue{}, fmt.Errorf("%s: TOTALDISCS %sが組の枚数%dと一致しません", s.CuePath(number), rem.TotalDiscs, s.TotalDiscs())
+	}
+	rem.DiscNumber = discNumber
+	rem.TotalDiscs = totalDiscs
+	disc := s.Discs[number-1]
+	if disc.Subtitle != "" {
+		rem.DiscSubtitle = disc.Subtitle
+	}
+	field := &cueFile.Album.Field
+	for _, shared := range []struct {
+		value  string
+		target *string
+	}{
+		{s.Album.Title, &field.Title},
+		{s.Album.Performer, &field.Performer},
+		{s.Album.Genre, &rem.Genre},
+		{s.Album.Date, &rem.Date},
+		{s.Album.Publisher, &rem.Publisher},
+		{s.Album.Label, &rem.Label},
+		{s.Album.Composer, &rem.Composer},
+		{s.Album.Comment, &rem.Comment},
+	} {
+		if shared.value != "" {
+			*shared.target = shared.value
+		}
+	}
+	return cueFile, nil
+}
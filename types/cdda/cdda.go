@@ -1,9 +1,15 @@
 package cdda
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 	"unsafe"
 
+	"github.com/ryo-kagawa/Music/types/timecode"
 	"github.com/ryo-kagawa/go-utils/conditional"
 	"golang.org/x/sys/windows"
 )
@@ -21,51 +27,256 @@ type RAW_READ_INFO struct {
 	TrackMode   uint32
 }
 
-func msfToLBA(min, sec, frame byte) int {
-	return (((int(min) * 60) + int(sec)) * 75) + int(frame)
+func msfToTimecode(msf [3]byte) timecode.Timecode {
+	return timecode.FromMSF(int(msf[0]), int(msf[1]), int(msf[2]))
 }
 
-func ReadAllSector(handle windows.Handle) ([]byte, error) {
-	toc, err := ReadTOC(handle)
-	if err != nil {
-		return nil, err
+// NOTE: ATIMEが設定されていない場合はPMIN/PSEC/PFRAMEを使用する
+func (c CDROM_TOC_FULL_TOC_DATA_BLOCK) LBA() int {
+	return conditional.Value(
+		msfToTimecode(c.MsfExtra) != 0,
+		msfToTimecode(c.MsfExtra),
+		msfToTimecode(c.Msf),
+	).LBA()
+}
+
+func (c CDROM_TOC_FULL_TOC_DATA) track01LBA() int {
+	for _, descriptor := range c.Descriptors {
+		if descriptor.Point == 0x01 {
+			return descriptor.LBA()
+		}
 	}
+	return 0
+}
 
+func (c CDROM_TOC_FULL_TOC_DATA) SectorCount() int {
 	leadOutLBA := 0
-	track01LBA := 0
-	for _, descriptor := range toc.Descriptors {
-		switch descriptor.Point {
-		case 0x00:
-		case 0x01:
-			track01LBA = conditional.Value(
-				msfToLBA(descriptor.MsfExtra[0], descriptor.MsfExtra[1], descriptor.MsfExtra[2]) != 0,
-				msfToLBA(descriptor.MsfExtra[0], descriptor.MsfExtra[1], descriptor.MsfExtra[2]),
-				msfToLBA(descriptor.Msf[0], descriptor.Msf[1], descriptor.Msf[2]),
-			)
-		case 0xA0:
-		case 0xA1:
-		case 0xA2:
-			leadOutLBA = conditional.Value(
-				msfToLBA(descriptor.MsfExtra[0], descriptor.MsfExtra[1], descriptor.MsfExtra[2]) != 0,
-				msfToLBA(descriptor.MsfExtra[0], descriptor.MsfExtra[1], descriptor.MsfExtra[2]),
-				msfToLBA(descriptor.Msf[0], descriptor.Msf[1], descriptor.Msf[2]),
+	for _, descriptor := range c.Descriptors {
+		if descriptor.Point == 0xA2 {
+			leadOutLBA = descriptor.LBA()
+		}
+	}
+	return leadOutLBA - c.track01LBA()
+}
+
+type Track struct {
+	Number int
+	// トラック01の開始位置を0とした位置
+	StartLBA int
+	EndLBA   int
+	Control  byte
+}
+
+func (t Track) SectorCount() int {
+	return t.EndLBA - t.StartLBA
+}
+func (t Track) HasAudioWithPreEmphasis() bool {
+	return t.Control&CDROM_TOC_FULL_TOC_DATA_BLOCK_CONTROL_AUDIO_WITH_PREEMPHASIS != 0
+}
+func (t Track) HasDigitalCopyPermited() bool {
+	return t.Control&CDROM_TOC_FULL_TOC_DATA_BLOCK_CONTROL_DIGITAL_COPY_PERMITTED != 0
+}
+func (t Track) HasAudioDataTrack() bool {
+	return t.Control&CDROM_TOC_FULL_TOC_DATA_BLOCK_CONTROL_AUDIO_DATA_TRACK != 0
+}
+func (t Track) HasTwoFourChannelAudio() bool {
+	return t.Control&CDROM_TOC_FULL_TOC_DATA_BLOCK_CONTROL_TWO_FOUR_CHANNEL_AUDIO != 0
+}
+
+func (c CDROM_TOC_FULL_TOC_DATA) Tracks() []Track {
+	track01LBA := c.track01LBA()
+	tracks := []Track{}
+	for _, descriptor := range c.Descriptors {
+		if 0x01 <= descriptor.Point && descriptor.Point <= 0x63 {
+			tracks = append(
+				tracks,
+				Track{
+					Number:   int(descriptor.Point),
+					StartLBA: descriptor.LBA() - track01LBA,
+					Control:  descriptor.GetControl(),
+				},
 			)
-		case 0xB0:
-		case 0xB1:
 		}
 	}
-	endLBA := leadOutLBA - track01LBA
+	slices.SortFunc(tracks, func(a Track, b Track) int {
+		return a.Number - b.Number
+	})
+	for i := range tracks {
+		tracks[i].EndLBA = conditional.Func(
+			i != len(tracks)-1,
+			func() int {
+				return tracks[i+1].StartLBA
+			},
+			func() int {
+				return c.SectorCount()
+			},
+		)
+	}
+	return tracks
+}
+
+// 読み込みの進捗
+type Progress struct {
+	LBA      int
+	StartLBA int
+	EndLBA   int
+	// 開始からの再試行回数の合計
+	Retries int
+	// 等速(75セクタ/秒)に対する読み込み速度(算出できない場合は0)
+	Speed float64
+	// 残り時間の見込み(算出できない場合は0)
+	ETA time.Duration
+}
+
+func (p Progress) Total() int {
+	return p.EndLBA - p.StartLBA
+}
+
+func (p Progress) Done() int {
+	return p.LBA - p.StartLBA
+}
+
+type ReadMode string
+
+const (
+	// 1回の読み込みで確定する
+	ReadModeBurst ReadMode = "burst"
+	// 連続した2回の読み込み結果が一致するまで読み込む
+	ReadModeSecure ReadMode = "secure"
+)
+
+// 再試行しても読み込めなかった場合の動作
+type ErrorAction string
+
+const (
+	// 読み込みを中断する
+	ErrorActionFail ErrorAction = "fail"
+	// 無音に置き換えて読み込みを続ける
+	ErrorActionSilence ErrorAction = "silence"
+)
+
+// 読み込めなかったセクタの再試行の方針
+type RetryPolicy struct {
+	// 1セクタあたりの再試行回数
+	Retries int
+	// 最初の再試行までの待ち時間、再試行毎に2倍にする
+	Backoff time.Duration
+	// 再試行時の読み込み速度(等速に対する倍率)、0の場合は速度を変更しない
+	ReducedSpeed int
+	// 再試行しても読み込めなかった場合の動作
+	OnError ErrorAction
+}
+
+type ReadOption struct {
+	Mode ReadMode
+	// 読み込み速度(等速に対する倍率)、0の場合はドライブの最大速度
+	// NOTE: 再試行で速度を落とした後はこの速度に戻す
+	Speed int
+	Retry RetryPolicy
+	// セクタを読み込む度に呼び出される
+	Progress func(Progress)
+	// 再試行の末に読み込めたセクタごとに呼び出される
+	Retried func(lba int, retries int)
+	// 無音に置き換えたセクタごとに呼び出される
+	Unreadable func(lba int, err error)
+}
+
+// startLBAからendLBAの手前までを1セクタずつ読み込み、読み込んだ順にfnへ渡す
+// NOTE: 読み込んだデータは保持しないため、ディスク全体を読み込んでもメモリ使用量は1セクタ分に収まる
+// NOTE: ctxがキャンセルされた場合はセクタ単位で中断し、ctx.Err()を返す
+func ReadSectors(ctx context.Context, handle windows.Handle, startLBA int, endLBA int, option ReadOption, fn func(lba int, sector []byte) error) error {
+	progress := Progress{
+		StartLBA: startLBA,
+		EndLBA:   endLBA,
+	}
+	if option.Speed != 0 {
+		// NOTE: 速度を変更できないドライブもあるため、失敗しても読み込みは続ける
+		SetSpeed(handle, option.Speed)
+	}
+	startTime := time.Now()
+	for lba := startLBA; lba < endLBA; lba++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sectorBuffer, retry, err := readSectorWithRetry(ctx, handle, lba, option)
+		progress.Retries += retry
+		switch {
+		case err == nil:
+			if retry != 0 && option.Retried != nil {
+				option.Retried(lba, retry)
+			}
+		case ctx.Err() == nil && option.Retry.OnError == ErrorActionSilence:
+			sectorBuffer = make([]byte, RAW_SECTOR_SIZE)
+			if option.Unreadable != nil {
+				option.Unreadable(lba, err)
+			}
+		default:
+			return err
+		}
+		if err := fn(lba, sectorBuffer); err != nil {
+			return err
+		}
+		if option.Progress != nil {
+			progress.LBA = lba + 1
+			// NOTE: 開始直後は経過時間が0に近く速度が無限大となるため、算出できるまで0とする
+			elapsed := time.Since(startTime)
+			if 0 < elapsed && 0 < progress.Done() {
+				sectorsPerSecond := float64(progress.Done()) / elapsed.Seconds()
+				progress.Speed = sectorsPerSecond / 75
+				progress.ETA = time.Duration(float64(endLBA-progress.LBA) / sectorsPerSecond * float64(time.Second))
+			}
+			option.Progress(progress)
+		}
+	}
+
+	return nil
+}
 
-	result := make([]byte, 0, endLBA*RAW_SECTOR_SIZE)
-	for lba := range endLBA {
-		sectorBuffer, err := ReadSector(handle, lba)
-		if err != nil {
-			return nil, fmt.Errorf("lba: %d not read: %v", lba, err)
+func readSectorWithRetry(ctx context.Context, handle windows.Handle, lba int, option ReadOption) ([]byte, int, error) {
+	sectorBuffer, err := readSectorWithMode(handle, lba, option.Mode)
+	if err == nil {
+		return sectorBuffer, 0, nil
+	}
+	if option.Retry.ReducedSpeed != 0 && option.Retry.Retries != 0 {
+		// NOTE: 速度を落とせないドライブもあるため、失敗しても再試行は続ける
+		SetSpeed(handle, option.Retry.ReducedSpeed)
+		defer SetSpeed(handle, option.Speed)
+	}
+	backoff := option.Retry.Backoff
+	for retry := 1; retry <= option.Retry.Retries; retry++ {
+		if backoff != 0 {
+			select {
+			case <-ctx.Done():
+				return nil, retry - 1, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, retry - 1, err
+		}
+		sectorBuffer, err = readSectorWithMode(handle, lba, option.Mode)
+		if err == nil {
+			return sectorBuffer, retry, nil
 		}
-		result = append(result, sectorBuffer...)
 	}
+	return nil, option.Retry.Retries, fmt.Errorf("lba: %d not read: %v", lba, err)
+}
 
-	return result, nil
+func readSectorWithMode(handle windows.Handle, lba int, mode ReadMode) ([]byte, error) {
+	sectorBuffer, err := ReadSector(handle, lba)
+	if err != nil || mode != ReadModeSecure {
+		return sectorBuffer, err
+	}
+	// NOTE: 2回読み込んだ結果が一致した場合に確定する
+	compareBuffer, err := ReadSector(handle, lba)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(sectorBuffer, compareBuffer) {
+		return nil, errors.New("data did not match")
+	}
+	return sectorBuffer, nil
 }
 
 func ReadSector(handle windows.Handle, sector int) ([]byte, error) {
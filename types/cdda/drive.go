@@ -0,0 +1,78 @@
+package cdda
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const IOCTL_STORAGE_QUERY_PROPERTY = 0x002D1400
+
+const (
+	STORAGE_DEVICE_PROPERTY = 0
+	PROPERTY_STANDARD_QUERY = 0
+)
+
+type STORAGE_PROPERTY_QUERY struct {
+	PropertyId           uint32
+	QueryType            uint32
+	AdditionalParameters [1]byte
+}
+
+type Drive struct {
+	Vendor   string
+	Product  string
+	Revision string
+}
+
+func (d Drive) String() string {
+	return strings.Join(
+		[]string{
+			d.Vendor,
+			d.Product,
+			d.Revision,
+		},
+		" ",
+	)
+}
+
+func ReadDrive(handle windows.Handle) (Drive, error) {
+	query := STORAGE_PROPERTY_QUERY{
+		PropertyId: STORAGE_DEVICE_PROPERTY,
+		QueryType:  PROPERTY_STANDARD_QUERY,
+	}
+	buffer := make([]byte, 1024)
+	if err := windows.DeviceIoControl(
+		handle,
+		IOCTL_STORAGE_QUERY_PROPERTY,
+		(*byte)(unsafe.Pointer(&query)),
+		uint32(unsafe.Sizeof(query)),
+		&buffer[0],
+		uint32(len(buffer)),
+		new(uint32),
+		nil,
+	); err != nil {
+		return Drive{}, err
+	}
+
+	// STORAGE_DEVICE_DESCRIPTOR
+	// 12-15: VendorIdOffset
+	// 16-19: ProductIdOffset
+	// 20-23: ProductRevisionOffset
+	return Drive{
+		Vendor:   descriptorString(buffer, binary.LittleEndian.Uint32(buffer[12:16])),
+		Product:  descriptorString(buffer, binary.LittleEndian.Uint32(buffer[16:20])),
+		Revision: descriptorString(buffer, binary.LittleEndian.Uint32(buffer[20:24])),
+	}, nil
+}
+
+func descriptorString(buffer []byte, offset uint32) string {
+	if offset == 0 || len(buffer) <= int(offset) {
+		return ""
+	}
+	value, _, _ := bytes.Cut(buffer[offset:], []byte{0x00})
+	return strings.TrimSpace(string(value))
+}
@@ -0,0 +1,52 @@
+package cdda
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const IOCTL_CDROM_SET_SPEED = 0x00024060
+
+const (
+	CdromSetSpeed        = 0
+	CdromDefaultRotation = 0
+)
+
+// 等速(1倍速)の転送量(KB/秒)
+const speedKiloBytesPerSecond = 176
+
+// 最大速度を指定する値
+const maxSpeed = 0xFFFF
+
+type CDROM_SET_SPEED struct {
+	RequestType     uint32
+	ReadSpeed       uint16
+	WriteSpeed      uint16
+	RotationControl uint32
+}
+
+// 読み込み速度を変更する
+// NOTE: speedは等速に対する倍率で、0の場合はドライブの最大速度とする
+func SetSpeed(handle windows.Handle, speed int) error {
+	readSpeed := uint16(maxSpeed)
+	if speed != 0 {
+		readSpeed = uint16(min(speed*speedKiloBytesPerSecond, maxSpeed))
+	}
+	setSpeed := CDROM_SET_SPEED{
+		RequestType:     CdromSetSpeed,
+		ReadSpeed:       readSpeed,
+		WriteSpeed:      maxSpeed,
+		RotationControl: CdromDefaultRotation,
+	}
+	return windows.DeviceIoControl(
+		handle,
+		IOCTL_CDROM_SET_SPEED,
+		(*byte)(unsafe.Pointer(&setSpeed)),
+		uint32(unsafe.Sizeof(setSpeed)),
+		nil,
+		0,
+		new(uint32),
+		nil,
+	)
+}
@@ -0,0 +1,107 @@
+package cue
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/ryo-kagawa/Music/types/deemphasis"
+	"github.com/ryo-kagawa/Music/types/wave"
+)
+
+// 音声データの読み込み元
+// NOTE: 音声データはメモリに読み込まず、出力時にファイルから順に読み込む
+type Audio struct {
+	// 読み込み元のファイル
+	Path string
+	// 読み込み元のファイル内でのdataチャンクの内容の位置とサイズ
+	Offset int64
+	Size   int64
+	// ビッグエンディアンで格納されたサンプルの1サンプルあたりのバイト数
+	// NOTE: 0以外の場合は出力時にリトルエンディアンへ変換する
+	SwapBytes int
+	// 出力時に16bitから24bitに変換しながらディエンファシスを適用する
+	Deemphasis bool
+}
+
+// 出力する音声データのサイズ
+func (a Audio) OutputSize() int64 {
+	if a.Deemphasis {
+		return a.Size / 2 * 3
+	}
+	return a.Size
+}
+
+// 音声データをwriterへ書き込む
+func (a Audio) WriteTo(writer io.Writer) (int64, error) {
+	source, err := os.Open(a.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+	if a.Deemphasis {
+		writer = deemphasis.NewWriter(writer)
+	}
+	if a.SwapBytes != 0 {
+		writer = &byteSwapWriter{
+			writer: writer,
+			size:   a.SwapBytes,
+		}
+	}
+	return io.Copy(writer, io.NewSectionReader(source, a.Offset, a.Size))
+}
+
+// サンプル毎にバイト順を反転しながら書き込む
+// NOTE: サンプルの途中で分割された場合は残りを保持して次の書き込みで処理する
+type byteSwapWriter struct {
+	writer    io.Writer
+	size      int
+	remainder []byte
+}
+
+func (w *byteSwapWriter) Write(data []byte) (int, error) {
+	buffer := append(w.remainder, data...)
+	length := len(buffer) / w.size * w.size
+	w.remainder = slices.Clone(buffer[length:])
+	output := make([]byte, length)
+	for i := 0; i < length; i += w.size {
+		for j := range w.size {
+			output[i+j] = buffer[i+w.size-1-j]
+		}
+	}
+	if _, err := w.writer.Write(output); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// WAVEファイルとして出力する
+// NOTE: 読み込み元と同じパスに出力する場合に備えて一時ファイルに書き込んでから置き換える
+func writeWave(path string, waveInfo wave.Wave, audio Audio) error {
+	tempPath := path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempPath)
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	dataSize := audio.OutputSize()
+	if _, err := writer.Write(waveInfo.Header(dataSize)); err != nil {
+		return err
+	}
+	if _, err := audio.WriteTo(writer); err != nil {
+		return err
+	}
+	if _, err := writer.Write(waveInfo.Trailer(dataSize)); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
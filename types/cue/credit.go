@@ -0,0 +1,104 @@
+package cue
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// トラックの担当者
+type Credit struct {
+	// 担当(REMのキー)
+	Role string
+	Name string
+}
+
+// 担当の種類
+type Role struct {
+	// CUEシートのREMのキー
+	Key string
+	// Vorbisコメントのタグ名
+	Tag string
+	// 演奏者の場合の楽器名
+	// NOTE: 指定した場合は「名前 (楽器名)」をタグの値とする
+	Instrument string
+}
+
+// 既知の担当の種類
+var roles = []Role{
+	// 作曲者
+	{Key: "COMPOSER", Tag: "COMPOSER"},
+	// 作詞者
+	{Key: "LYRICIST", Tag: "LYRICIST"},
+	// 編曲者
+	{Key: "ARRANGER", Tag: "ARRANGER"},
+	// リミックス者
+	{Key: "REMIXER", Tag: "REMIXER"},
+	{Key: "GUITAR", Tag: "PERFORMER", Instrument: "guitar"},
+	{Key: "ELECTRIC_GUITAR", Tag: "PERFORMER", Instrument: "electric guitar"},
+	{Key: "BASS", Tag: "PERFORMER", Instrument: "bass"},
+	{Key: "ELECTRIC_BASS", Tag: "PERFORMER", Instrument: "electric bass"},
+	{Key: "KEYBOARDS", Tag: "PERFORMER", Instrument: "keyboards"},
+	{Key: "SYNTHESIZER", Tag: "PERFORMER", Instrument: "synthesizer"},
+	{Key: "ANALOG_SYNTHESIZER", Tag: "PERFORMER", Instrument: "analog synthesizer"},
+	{Key: "HORN", Tag: "PERFORMER", Instrument: "horn"},
+	{Key: "DRUMS", Tag: "PERFORMER", Instrument: "drums"},
+	{Key: "PERCUSSIONS", Tag: "PERFORMER", Instrument: "percussion"},
+	{Key: "VOCAL", Tag: "PERFORMER", Instrument: "vocals"},
+	{Key: "BACKING_VOCAL", Tag: "PERFORMER", Instrument: "backing vocals"},
+}
+
+// 担当の種類を追加する
+// NOTE: 同じキーが登録済みの場合は置き換える
+func RegisterRole(role Role) error {
+	role.Key = strings.ToUpper(role.Key)
+	if role.Key == "" || strings.ContainsFunc(role.Key, isSpace) {
+		return fmt.Errorf("担当のキー\"%s\"が不正です", role.Key)
+	}
+	if role.Tag == "" {
+		role.Tag = role.Key
+	}
+	index := slices.IndexFunc(roles, func(r Role) bool {
+		return r.Key == role.Key
+	})
+	if index < 0 {
+		roles = append(roles, role)
+	} else {
+		roles[index] = role
+	}
+	return nil
+}
+
+// キーに対応する担当の種類
+func LookupRole(key string) (Role, bool) {
+	key = strings.ToUpper(key)
+	for _, role := range roles {
+		if role.Key == key {
+			return role, true
+		}
+	}
+	return Role{}, false
+}
+
+// 指定した担当の名前
+func (t TrackField) CreditNames(role string) []string {
+	names := []string{}
+	for _, credit := range t.Credits {
+		if credit.Role == role {
+			names = append(names, credit.Name)
+		}
+	}
+	return names
+}
+
+// 担当者のタグ
+func (c Credit) Tag() Tag {
+	role, ok := LookupRole(c.Role)
+	if !ok {
+		return Tag{Name: c.Role, Value: c.Name}
+	}
+	if role.Instrument != "" {
+		return Tag{Name: role.Tag, Value: fmt.Sprintf("%s (%s)", c.Name, role.Instrument)}
+	}
+	return Tag{Name: role.Tag, Value: c.Name}
+}
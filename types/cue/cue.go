@@ -1,20 +1,21 @@
 package cue
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
-	"github.com/ryo-kagawa/Music/utils"
+	"github.com/ryo-kagawa/Music/types/timecode"
+	"github.com/ryo-kagawa/Music/types/wave"
 	"github.com/ryo-kagawa/go-utils/conditional"
 )
 
-// CDDAからWAVE変換を行った前提で処理する
+// NOTE: CDDA以外(ハイレゾ・DVDなど)のWAVEファイルもサンプリングレートとブロックアラインメントから位置を求めて処理する
+
+// 以下はCDDAの形式
 
 // 44.1kHz
 const samplingRate = 44100
@@ -26,49 +27,73 @@ const bitDepth = 16 / 8
 const channels = 2
 
 // 75フレーム
-const frames = 75
+const frames = timecode.FramesPerSecond
 
 // 44.1kHz * 16bit量子化 * ステレオ / フレーム数
 const FrameSize = samplingRate * bitDepth * channels / frames
-const HeaderSize = 44
+
+type Index struct {
+	// 00から99
+	Number   int
+	Position timecode.Timecode
+}
 
 type TrackSubCommand struct {
-	Isrc  string
-	Index struct {
-		Index00 string
-		Index01 string
+	Isrc string
+	// ファイルに含まれない無音のギャップ
+	// 未指定の場合はnil
+	Pregap  *timecode.Timecode
+	Postgap *timecode.Timecode
+	// 番号の昇順
+	Indexes []Index
+}
+
+// 指定した番号のインデックスの位置
+func (s TrackSubCommand) Index(number int) (timecode.Timecode, bool) {
+	for _, index := range s.Indexes {
+		if index.Number == number {
+			return index.Position, true
+		}
+	}
+	return 0, false
+}
+
+// トラックの開始位置(INDEX 01)
+func (s TrackSubCommand) Start() timecode.Timecode {
+	position, _ := s.Index(1)
+	return position
+}
+
+// ギャップを含めたトラックの開始位置(INDEX 00、無い場合はINDEX 01)
+func (s TrackSubCommand) GapStart() timecode.Timecode {
+	if position, ok := s.Index(0); ok {
+		return position
 	}
+	return s.Start()
+}
+
+// 未対応のキーのREM
+type Rem struct {
+	Key   string
+	Value string
 }
 
 type TrackCommand struct {
-	Track      int
+	Track int
+	// TRACKの種類(空の場合はAUDIO)
+	DataType   string
 	SubCommand TrackSubCommand
 }
 
 type TrackField struct {
-	Title     string
-	Performer string
-	Rem       struct {
-		// 作曲者
-		Composer string
-		// 作詞者
-		Lyricist          string
-		Guitar            string
-		ElectricGuitar    string
-		Bass              string
-		ElectricBass      string
-		Keyboards         string
-		Synthesizer       string
-		AnalogSynthesizer string
-		Horn              string
-		Drums             string
-		Percussions       string
-		// 編曲者
-		Arranger string
-		// リミックス者
-		Remixer      string
-		Vocal        string
-		BackingVocal string
+	Title      string
+	Performer  string
+	Songwriter string
+	// REMに記録された担当者(記録順)
+	Credits []Credit
+	Rem     struct {
+		// 未対応のキーのREM
+		Unknown []Rem
 	}
 	Flags struct {
 		// DCP
@@ -85,13 +110,19 @@ type TrackField struct {
 type Track struct {
 	Command TrackCommand
 	Field   TrackField
+	// TRACKから次のTRACK・FILEまでの読み込み時の行
+	Lines []Line
 }
 
 type File struct {
-	Name   string
-	Type   string
-	Binary []byte
+	Name string
+	Type string
+	// WAVEファイルの形式とfmt・data以外のチャンク
+	Wave   wave.Wave
+	Audio  Audio
 	Tracks []Track
+	// FILEから最初のTRACKまでの読み込み時の行
+	Lines []Line
 }
 
 type AlbumCommand struct {
@@ -117,299 +148,97 @@ type AlbumField struct {
 		Composer    string
 		DiscNumber  string
 		TotalDiscs  string
-		DiscId      string
-		Jan         string
-		Comment     string
+		// 複数枚組の各ディスクの副題
+		DiscSubtitle string
+		DiscId       string
+		Jan          string
+		Comment      string
+		// 取り込み時に読み込みに問題があった範囲
+		ReadErrors []string
+		// 未対応のキーのREM
+		Unknown []Rem
 	}
 	// 型番
-	Catalog   string
-	Title     string
-	Performer string
+	Catalog string
+	// CD-TEXTのファイル名
+	CdTextFile string
+	Title      string
+	Performer  string
+	Songwriter string
 }
 
 type Album struct {
 	Command AlbumCommand
 	Field   AlbumField
+	// 最初のFILEまでの読み込み時の行
+	Lines []Line
 }
 
 type Cue struct {
-	Album Album
-}
-
-func Load(cueFilepath string) (Cue, error) {
-	cueData, err := utils.ReadTextFileToUTF8(cueFilepath)
-	if err != nil {
-		return Cue{}, err
-	}
-	albumFieldFlag := true
-	cue := Cue{}
-	currentFile := &File{}
-	currentTrack := &Track{}
-
-	for line := range utils.SplitNewLineWithoutEmpty(cueData) {
-		line = strings.TrimLeft(line, " ")
-		// NOTE: FILEはトラックフィールド行以降にも存在するアルバムフィールドなので例外的に処理する
-		if strings.HasPrefix(line, "FILE ") {
-			// NOTE: ファイル名に空白がある場合に対応するため、" "で分割できない
-			FileField := strings.TrimPrefix(line, "FILE ")
-			switch {
-			case strings.HasSuffix(FileField, " WAVE"):
-				fileName := utils.TrimQuotesIfWrapped(strings.TrimSuffix(FileField, " WAVE"))
-				waveFile, err := os.ReadFile(filepath.Join(filepath.Dir(cueFilepath), fileName))
-				if err != nil {
-					return Cue{}, err
-				}
-				if len(waveFile) < 44 {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// RIFF識別子の確認
-				if !bytes.Equal(waveFile[:4], []byte("RIFF")) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// WAVE識別子の確認
-				if !bytes.Equal(waveFile[8:12], []byte("WAVE")) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// fmt識別子の確認
-				if !bytes.Equal(waveFile[12:16], []byte("fmt ")) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// fmtチャンクサイズ
-				if !bytes.Equal(waveFile[16:20], []byte{0x10, 0x00, 0x00, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// フォーマットタイプの確認
-				if !bytes.Equal(waveFile[20:22], []byte{0x01, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// チャンネル数の確認
-				if !bytes.Equal(waveFile[22:24], []byte{0x02, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// サンプリングレートの確認
-				if !bytes.Equal(waveFile[24:28], []byte{0x44, 0xAC, 0x00, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// バイトレート
-				if !bytes.Equal(waveFile[28:32], []byte{0x10, 0xB1, 0x02, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// ブロックアラインメント
-				if !bytes.Equal(waveFile[32:34], []byte{0x04, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// ビット深度
-				if !bytes.Equal(waveFile[34:36], []byte{0x10, 0x00}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				// data識別子
-				if !bytes.Equal(waveFile[36:40], []byte{0x64, 0x61, 0x74, 0x61}) {
-					return Cue{}, errors.New("WAVEフォーマットエラー")
-				}
-				cue.Album.Command.Files = append(
-					cue.Album.Command.Files,
-					File{
-						Name:   fileName,
-						Type:   "WAVE",
-						Binary: waveFile,
-					},
-				)
-			default:
-				return Cue{}, errors.New("CUEファイルが不正です")
-			}
-			currentFile = &cue.Album.Command.Files[len(cue.Album.Command.Files)-1]
-			albumFieldFlag = false
-			continue
-		}
-		if albumFieldFlag {
-			// NOTE: アルバム
-			switch {
-			case strings.HasPrefix(line, "REM "):
-				remField := strings.TrimPrefix(line, "REM ")
-				switch {
-				case strings.HasPrefix(remField, "GENRE "):
-					cue.Album.Field.Rem.Genre = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "GENRE "))
-				case strings.HasPrefix(remField, "DATE "):
-					cue.Album.Field.Rem.Date = strings.TrimPrefix(remField, "DATE ")
-				case strings.HasPrefix(remField, "PUBLISHER "):
-					cue.Album.Field.Rem.Publisher = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "PUBLISHER "))
-				case strings.HasPrefix(remField, "LABEL "):
-					cue.Album.Field.Rem.Label = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "LABEL "))
-				case strings.HasPrefix(remField, "PRODUCER "):
-					cue.Album.Field.Rem.Producer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "PRODUCER "))
-				case strings.HasPrefix(remField, "PRODUCTION "):
-					cue.Album.Field.Rem.Production = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "PRODUCTION "))
-				case strings.HasPrefix(remField, "WORK "):
-					cue.Album.Field.Rem.Work = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "WORK "))
-				case strings.HasPrefix(remField, "BGM_WORK "):
-					cue.Album.Field.Rem.BGMWork = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "BGM_WORK "))
-				case strings.HasPrefix(remField, "BGM_DIRECTOR "):
-					cue.Album.Field.Rem.BGMDirector = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "BGM_DIRECTOR "))
-				case strings.HasPrefix(remField, "COMPOSER "):
-					cue.Album.Field.Rem.Composer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "COMPOSER "))
-				case strings.HasPrefix(remField, "DISCNUMBER "):
-					cue.Album.Field.Rem.DiscId = strings.TrimPrefix(remField, "DISCNUMBER ")
-				case strings.HasPrefix(remField, "TOTALDISCS "):
-					cue.Album.Field.Rem.DiscId = strings.TrimPrefix(remField, "TOTALDISCS ")
-				case strings.HasPrefix(remField, "DISCID "):
-					cue.Album.Field.Rem.DiscId = strings.TrimPrefix(remField, "DISCID ")
-				case strings.HasPrefix(remField, "JAN "):
-					cue.Album.Field.Rem.Jan = strings.TrimPrefix(remField, "JAN ")
-				case strings.HasPrefix(remField, "COMMENT "):
-					cue.Album.Field.Rem.Comment = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "COMMENT "))
-				default:
-					return Cue{}, fmt.Errorf("アルバムフィールドの\"%s\"に未対応です", line)
-				}
-			case strings.HasPrefix(line, "CATALOG "):
-				cue.Album.Field.Catalog = strings.TrimPrefix(line, "CATALOG ")
-			case strings.HasPrefix(line, "TITLE "):
-				// NOTE: 「""」となっている場合は「"」に変換して扱う
-				cue.Album.Field.Title = strings.ReplaceAll(utils.TrimQuotesIfWrapped(strings.TrimPrefix(line, "TITLE ")), "\"\"", "\"")
-			case strings.HasPrefix(line, "PERFORMER "):
-				cue.Album.Field.Performer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(line, "PERFORMER "))
-			default:
-				return Cue{}, fmt.Errorf("アルバムフィールドの\"%s\"に未対応です", line)
-			}
-			continue
-		}
-		// NOTE: トラック
-		switch {
-		case strings.HasPrefix(line, "TRACK "):
-			trackField := strings.TrimPrefix(line, "TRACK ")
-			switch {
-			case strings.HasSuffix(trackField, " AUDIO"):
-				number, err := strconv.Atoi(strings.TrimSuffix(trackField, " AUDIO"))
-				if err != nil {
-					return Cue{}, err
-				}
-				currentFile.Tracks = append(
-					currentFile.Tracks,
-					Track{
-						Command: TrackCommand{
-							Track: number,
-						},
-					},
-				)
-			default:
-				return Cue{}, errors.New("CUEファイルが不正です")
-			}
-			currentTrack = &currentFile.Tracks[len(currentFile.Tracks)-1]
-		case strings.HasPrefix(line, "ISRC "):
-			currentTrack.Command.SubCommand.Isrc = utils.TrimQuotesIfWrapped(strings.TrimPrefix(line, "ISRC "))
-		case strings.HasPrefix(line, "TITLE "):
-			// NOTE: 「""」となっている場合は「"」に変換して扱う
-			currentTrack.Field.Title = strings.ReplaceAll(utils.TrimQuotesIfWrapped(strings.TrimPrefix(line, "TITLE ")), "\"\"", "\"")
-		case strings.HasPrefix(line, "PERFORMER "):
-			currentTrack.Field.Performer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(line, "PERFORMER "))
-		case strings.HasPrefix(line, "REM "):
-			remField := strings.TrimPrefix(line, "REM ")
-			switch {
-			case strings.HasPrefix(remField, "COMPOSER "):
-				currentTrack.Field.Rem.Composer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "COMPOSER "))
-			case strings.HasPrefix(remField, "LYRICIST "):
-				currentTrack.Field.Rem.Lyricist = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "LYRICIST "))
-			case strings.HasPrefix(remField, "GUITAR "):
-				currentTrack.Field.Rem.Guitar = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "GUITAR "))
-			case strings.HasPrefix(remField, "ELECTRIC_GUITAR "):
-				currentTrack.Field.Rem.ElectricGuitar = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "ELECTRIC_GUITAR "))
-			case strings.HasPrefix(remField, "BASS "):
-				currentTrack.Field.Rem.Bass = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "BASS "))
-			case strings.HasPrefix(remField, "ELECTRIC_BASS "):
-				currentTrack.Field.Rem.ElectricBass = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "ELECTRIC_BASS "))
-			case strings.HasPrefix(remField, "KEYBOARDS "):
-				currentTrack.Field.Rem.Keyboards = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "KEYBOARDS "))
-			case strings.HasPrefix(remField, "SYNTHESIZER "):
-				currentTrack.Field.Rem.Synthesizer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "SYNTHESIZER "))
-			case strings.HasPrefix(remField, "ANALOG_SYNTHESIZER "):
-				currentTrack.Field.Rem.AnalogSynthesizer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "ANALOG_SYNTHESIZER "))
-			case strings.HasPrefix(remField, "HORN "):
-				currentTrack.Field.Rem.Horn = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "HORN "))
-			case strings.HasPrefix(remField, "DRUMS "):
-				currentTrack.Field.Rem.Drums = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "DRUMS "))
-			case strings.HasPrefix(remField, "PERCUSSIONS "):
-				currentTrack.Field.Rem.Percussions = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "PERCUSSIONS "))
-			case strings.HasPrefix(remField, "ARRANGER "):
-				currentTrack.Field.Rem.Arranger = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "ARRANGER "))
-			case strings.HasPrefix(remField, "REMIXER "):
-				currentTrack.Field.Rem.Remixer = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "REMIXER "))
-			case strings.HasPrefix(remField, "VOCAL "):
-				currentTrack.Field.Rem.Vocal = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "VOCAL "))
-			case strings.HasPrefix(remField, "BACKING_VOCAL "):
-				currentTrack.Field.Rem.BackingVocal = utils.TrimQuotesIfWrapped(strings.TrimPrefix(remField, "BACKING_VOCAL "))
-			default:
-				return Cue{}, fmt.Errorf("トラックフィールドの\"%s\"に未対応です", line)
-			}
-		case strings.HasPrefix(line, "FLAGS "):
-			flags := utils.TrimQuotesIfWrapped(strings.TrimPrefix(line, "FLAGS "))
-			for flag := range strings.SplitSeq(flags, " ") {
-				switch flag {
-				case "DCP":
-					currentTrack.Field.Flags.DigitalCopyPermitted = true
-				case "4CH":
-					currentTrack.Field.Flags.FourChannelAudio = true
-				case "PRE":
-					currentTrack.Field.Flags.PreEmphasisEnabled = true
-				case "SCMS":
-					currentTrack.Field.Flags.SerialCopyManagementSystem = true
-				}
-			}
-		case strings.HasPrefix(line, "INDEX "):
-			indexParameter := strings.TrimPrefix(line, "INDEX ")
-			switch {
-			case strings.HasPrefix(indexParameter, "00 "):
-				currentTrack.Command.SubCommand.Index.Index00 = strings.TrimPrefix(line, "INDEX 00 ")
-			case strings.HasPrefix(indexParameter, "01 "):
-				currentTrack.Command.SubCommand.Index.Index01 = strings.TrimPrefix(line, "INDEX 01 ")
-			default:
-				return Cue{}, fmt.Errorf("トラックフィールドの\"%s\"に未対応です", line)
-			}
-		default:
-			return Cue{}, fmt.Errorf("トラックフィールドの\"%s\"に未対応です", line)
-		}
-	}
-	return cue, nil
+	Album  Album
+	Layout Layout
 }
 
 func (c Cue) SplitTrack() Cue {
 	cue := c
 	cue.Album.Command.Files = []File{}
 	for _, file := range c.Album.Command.Files {
+		samplingRate := int(file.Wave.Format.SamplingRate)
+		blockAlign := int(file.Wave.Format.BlockAlign)
+		size := int(file.Audio.Size)
 		for trackIndex, track := range file.Tracks {
-			mm, _ := strconv.Atoi(track.Command.SubCommand.Index.Index01[0:2])
-			ss, _ := strconv.Atoi(track.Command.SubCommand.Index.Index01[3:5])
-			ff, _ := strconv.Atoi(track.Command.SubCommand.Index.Index01[6:8])
-			start := HeaderSize + ((mm*60+ss)*75+ff)*FrameSize
+			// NOTE: データトラックは音声として出力しない
+			if !track.IsAudio() {
+				continue
+			}
+			trackStart := track.Command.SubCommand.Start()
+			start := min(trackStart.ByteOffset(samplingRate, blockAlign), size)
 			end := conditional.Func(
 				trackIndex != len(file.Tracks)-1,
 				func() int {
-					index := conditional.Value(
-						file.Tracks[trackIndex+1].Command.SubCommand.Index.Index00 != "",
-						file.Tracks[trackIndex+1].Command.SubCommand.Index.Index00,
-						file.Tracks[trackIndex+1].Command.SubCommand.Index.Index01,
-					)
-					mm, _ := strconv.Atoi(index[0:2])
-					ss, _ := strconv.Atoi(index[3:5])
-					ff, _ := strconv.Atoi(index[6:8])
-					return HeaderSize + ((mm*60+ss)*75+ff)*FrameSize
+					nextStart := file.Tracks[trackIndex+1].Command.SubCommand.GapStart()
+					return min(nextStart.ByteOffset(samplingRate, blockAlign), size)
 				},
 				func() int {
-					return len(file.Binary)
+					return size
 				},
 			)
-			header := make([]byte, HeaderSize)
-			copy(header[0:4], file.Binary[:4])
-			binary.LittleEndian.PutUint32(header[4:8], uint32(end-start+HeaderSize-8))
-			copy(header[8:40], file.Binary[8:40])
-			binary.LittleEndian.PutUint32(header[40:], uint32(end-start))
+			// NOTE: INDEX 00はトラックの先頭より前のため除き、INDEX 01以降はトラックの先頭からの位置とする
+			// NOTE: PREGAP・POSTGAPはファイルに含まれないためそのまま引き継ぐ
 			newTrack := track
-			newTrack.Command.SubCommand.Index.Index00 = ""
-			newTrack.Command.SubCommand.Index.Index01 = "00:00:00"
+			newTrack.Command.SubCommand.Indexes = []Index{}
+			for _, index := range track.Command.SubCommand.Indexes {
+				if index.Number == 0 {
+					continue
+				}
+				newTrack.Command.SubCommand.Indexes = append(
+					newTrack.Command.SubCommand.Indexes,
+					Index{
+						Number:   index.Number,
+						Position: index.Position.Sub(trackStart),
+					},
+				)
+			}
+			// NOTE: LISTなどのチャンクは分割前のファイル全体の情報のため引き継がない
+			// NOTE: bextチャンクは開始位置をトラックの先頭に合わせて引き継ぐ
+			newWave := wave.New(file.Wave.Format)
+			if extension, ok, err := file.Wave.BroadcastExtension(); err == nil && ok {
+				extension.TimeReference += uint64(start / blockAlign)
+				newWave.SetBroadcastExtension(extension)
+			}
 			cue.Album.Command.Files = append(
 				cue.Album.Command.Files,
 				File{
-					Name:   fmt.Sprintf("%02d %s.wav", track.Command.Track, TitleToFileName(track.Field.Title)),
-					Type:   "WAVE",
-					Binary: append(header, file.Binary[start:end]...),
+					Name: TrackFileName(track, ".wav"),
+					Type: FileTypeWave,
+					Wave: newWave,
+					Audio: Audio{
+						Path:       file.Audio.Path,
+						Offset:     file.Audio.Offset + int64(start),
+						Size:       int64(end - start),
+						SwapBytes:  file.Audio.SwapBytes,
+						Deemphasis: file.Audio.Deemphasis,
+					},
 					Tracks: []Track{
 						newTrack,
 					},
@@ -423,9 +252,11 @@ func (c Cue) SplitTrack() Cue {
 func (c Cue) OutputWave(outputDirectory string) error {
 	for _, file := range c.Album.Command.Files {
 		if filepath.Ext(file.Name) == ".wav" {
+			if !file.HasAudio() {
+				return fmt.Errorf("%s: FILEの種類%sの音声データは読み込めません", file.Name, file.Type)
+			}
 			outPath := filepath.Join(outputDirectory, file.Name)
-			err := os.WriteFile(outPath, file.Binary, 0644)
-			if err != nil {
+			if err := writeWave(outPath, file.Wave, file.Audio); err != nil {
 				return err
 			}
 		}
@@ -433,152 +264,189 @@ func (c Cue) OutputWave(outputDirectory string) error {
 	return nil
 }
 
+// WAVEファイルのヘッダーのみを読み込む
+func readWave(path string) (wave.Wave, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return wave.Wave{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return wave.Wave{}, err
+	}
+	return wave.Read(file, info.Size())
+}
+
+// 分割できるWAVEファイルの形式であることを確認する
+func validateFormat(format wave.Format) error {
+	switch format.FormatTag {
+	case wave.FormatPCM, wave.FormatIEEEFloat:
+	default:
+		return fmt.Errorf("WAVEフォーマットエラー: リニアPCM・浮動小数点以外に未対応です(0x%04X)", format.FormatTag)
+	}
+	if format.SamplingRate == 0 {
+		return errors.New("WAVEフォーマットエラー: サンプリングレートが0です")
+	}
+	return nil
+}
+
+// NOTE: 読み込み時の文字コード・BOMの有無・改行コードで出力する
 func (c Cue) OutputCuefile(outputPath string) error {
-	output := ""
-	if c.Album.Field.Rem.Genre != "" {
-		output += fmt.Sprintf("REM GENRE \"%s\"\n", c.Album.Field.Rem.Genre)
+	return c.WriteCuefile(outputPath, WriteOptions{})
+}
+
+// CUEシートの各行
+// NOTE: 読み込み時の行がある場合は元の順序と表記を維持する
+func (c Cue) lines() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		emit := func(lines []string) bool {
+			for _, line := range lines {
+				if !yield(line) {
+					return false
+				}
+			}
+			return true
+		}
+		if !emit(merge(c.Album.Lines, c.Album.Field.entries())) {
+			return
+		}
+		for _, file := range c.Album.Command.Files {
+			if !emit(merge(file.Lines, file.entries())) {
+				return
+			}
+			for _, track := range file.Tracks {
+				if !emit(merge(track.Lines, track.entries())) {
+					return
+				}
+			}
+		}
 	}
-	if c.Album.Field.Rem.Date != "" {
-		output += fmt.Sprintf("REM DATE %s\n", c.Album.Field.Rem.Date)
+}
+
+// アルバムフィールドの出力内容
+func (a AlbumField) entries() []entry {
+	entries := []entry{}
+	add := func(key string, text string) {
+		entries = append(entries, entry{key: key, text: text})
 	}
-	if c.Album.Field.Rem.Publisher != "" {
-		output += fmt.Sprintf("REM PUBLISHER \"%s\"\n", c.Album.Field.Rem.Publisher)
+	if a.Rem.Genre != "" {
+		add("REM GENRE", "REM GENRE "+quote(a.Rem.Genre))
 	}
-	if c.Album.Field.Rem.Label != "" {
-		output += fmt.Sprintf("REM LABEL \"%s\"\n", c.Album.Field.Rem.Label)
+	if a.Rem.Date != "" {
+		add("REM DATE", "REM DATE "+a.Rem.Date)
 	}
-	if c.Album.Field.Rem.Producer != "" {
-		output += fmt.Sprintf("REM PRODUCER \"%s\"\n", c.Album.Field.Rem.Producer)
+	if a.Rem.Publisher != "" {
+		add("REM PUBLISHER", "REM PUBLISHER "+quote(a.Rem.Publisher))
 	}
-	if c.Album.Field.Rem.Production != "" {
-		output += fmt.Sprintf("REM PRODUCTION \"%s\"\n", c.Album.Field.Rem.Production)
+	if a.Rem.Label != "" {
+		add("REM LABEL", "REM LABEL "+quote(a.Rem.Label))
 	}
-	if c.Album.Field.Rem.Work != "" {
-		output += fmt.Sprintf("REM WORK \"%s\"\n", c.Album.Field.Rem.Work)
+	if a.Rem.Producer != "" {
+		add("REM PRODUCER", "REM PRODUCER "+quote(a.Rem.Producer))
 	}
-	if c.Album.Field.Rem.BGMWork != "" {
-		output += fmt.Sprintf("REM BGM_WORK \"%s\"\n", c.Album.Field.Rem.BGMWork)
+	if a.Rem.Production != "" {
+		add("REM PRODUCTION", "REM PRODUCTION "+quote(a.Rem.Production))
 	}
-	if c.Album.Field.Rem.BGMDirector != "" {
-		output += fmt.Sprintf("REM BGM_DIRECTOR \"%s\"\n", c.Album.Field.Rem.BGMDirector)
+	if a.Rem.Work != "" {
+		add("REM WORK", "REM WORK "+quote(a.Rem.Work))
 	}
-	if c.Album.Field.Rem.Composer != "" {
-		output += fmt.Sprintf("REM COMPOSER \"%s\"\n", c.Album.Field.Rem.Composer)
+	if a.Rem.BGMWork != "" {
+		add("REM BGM_WORK", "REM BGM_WORK "+quote(a.Rem.BGMWork))
 	}
-	if c.Album.Field.Rem.DiscNumber != "" {
-		output += fmt.Sprintf("REM DISCNUMBER %s\n", c.Album.Field.Rem.DiscNumber)
+	if a.Rem.BGMDirector != "" {
+		add("REM BGM_DIRECTOR", "REM BGM_DIRECTOR "+quote(a.Rem.BGMDirector))
 	}
-	if c.Album.Field.Rem.TotalDiscs != "" {
-		output += fmt.Sprintf("REM TOTALDISCS %s\n", c.Album.Field.Rem.TotalDiscs)
+	if a.Rem.Composer != "" {
+		add("REM COMPOSER", "REM COMPOSER "+quote(a.Rem.Composer))
 	}
-	if c.Album.Field.Rem.DiscId != "" {
-		output += fmt.Sprintf("REM DISCID %s\n", c.Album.Field.Rem.DiscId)
+	if a.Rem.DiscNumber != "" {
+		add("REM DISCNUMBER", "REM DISCNUMBER "+a.Rem.DiscNumber)
 	}
-	if c.Album.Field.Rem.Jan != "" {
-		output += fmt.Sprintf("REM JAN %s\n", c.Album.Field.Rem.Jan)
+	if a.Rem.TotalDiscs != "" {
+		add("REM TOTALDISCS", "REM TOTALDISCS "+a.Rem.TotalDiscs)
 	}
-	if c.Album.Field.Rem.Comment != "" {
-		output += fmt.Sprintf("REM COMMENT \"%s\"\n", c.Album.Field.Rem.Comment)
+	if a.Rem.DiscSubtitle != "" {
+		add("REM DISCSUBTITLE", "REM DISCSUBTITLE "+quote(a.Rem.DiscSubtitle))
 	}
-	if c.Album.Field.Catalog != "" {
-		output += fmt.Sprintf("CATALOG %s\n", c.Album.Field.Catalog)
+	if a.Rem.DiscId != "" {
+		add("REM DISCID", "REM DISCID "+a.Rem.DiscId)
 	}
-	if c.Album.Field.Title != "" {
-		output += fmt.Sprintf("TITLE \"%s\"\n", c.Album.Field.Title)
+	if a.Rem.Jan != "" {
+		add("REM JAN", "REM JAN "+a.Rem.Jan)
 	}
-	if c.Album.Field.Performer != "" {
-		output += fmt.Sprintf("PERFORMER \"%s\"\n", c.Album.Field.Performer)
+	if a.Rem.Comment != "" {
+		add("REM COMMENT", "REM COMMENT "+quote(a.Rem.Comment))
 	}
-	for _, file := range c.Album.Command.Files {
-		output += fmt.Sprintf("FILE \"%s\" WAVE\n", file.Name)
-		for _, track := range file.Tracks {
-			output += fmt.Sprintf("  TRACK %02d AUDIO\n", track.Command.Track)
-			if track.Command.SubCommand.Isrc != "" {
-				output += fmt.Sprintf("    ISRC %s\n", track.Command.SubCommand.Isrc)
-			}
-			// NOTE: 出力時に「"」を「""」とする
-			output += fmt.Sprintf("    TITLE \"%s\"\n", strings.ReplaceAll(track.Field.Title, "\"", "\"\""))
-			if track.Field.Performer != "" {
-				output += fmt.Sprintf("    PERFORMER \"%s\"\n", track.Field.Performer)
-			}
-			if track.Field.Rem.Composer != "" {
-				output += fmt.Sprintf("    REM COMPOSER \"%s\"\n", track.Field.Rem.Composer)
-			}
-			if track.Field.Rem.Lyricist != "" {
-				output += fmt.Sprintf("    REM LYRICIST \"%s\"\n", track.Field.Rem.Lyricist)
-			}
-			if track.Field.Rem.Guitar != "" {
-				output += fmt.Sprintf("    REM GUITAR \"%s\"\n", track.Field.Rem.Guitar)
-			}
-			if track.Field.Rem.ElectricGuitar != "" {
-				output += fmt.Sprintf("    REM ELECTRIC_GUITAR \"%s\"\n", track.Field.Rem.ElectricGuitar)
-			}
-			if track.Field.Rem.Bass != "" {
-				output += fmt.Sprintf("    REM BASS \"%s\"\n", track.Field.Rem.Bass)
-			}
-			if track.Field.Rem.ElectricBass != "" {
-				output += fmt.Sprintf("    REM ELECTRIC_BASS \"%s\"\n", track.Field.Rem.ElectricBass)
-			}
-			if track.Field.Rem.Keyboards != "" {
-				output += fmt.Sprintf("    REM KEYBOARDS \"%s\"\n", track.Field.Rem.Keyboards)
-			}
-			if track.Field.Rem.Synthesizer != "" {
-				output += fmt.Sprintf("    REM SYNTHESIZER \"%s\"\n", track.Field.Rem.Synthesizer)
-			}
-			if track.Field.Rem.AnalogSynthesizer != "" {
-				output += fmt.Sprintf("    REM ANALOG_SYNTHESIZER \"%s\"\n", track.Field.Rem.AnalogSynthesizer)
-			}
-			if track.Field.Rem.Horn != "" {
-				output += fmt.Sprintf("    REM HORN \"%s\"\n", track.Field.Rem.Horn)
-			}
-			if track.Field.Rem.Drums != "" {
-				output += fmt.Sprintf("    REM DRUMS \"%s\"\n", track.Field.Rem.Drums)
-			}
-			if track.Field.Rem.Percussions != "" {
-				output += fmt.Sprintf("    REM PERCUSSIONS \"%s\"\n", track.Field.Rem.Percussions)
-			}
-			if track.Field.Rem.Arranger != "" {
-				output += fmt.Sprintf("    REM ARRANGER \"%s\"\n", track.Field.Rem.Arranger)
-			}
-			if track.Field.Rem.Remixer != "" {
-				output += fmt.Sprintf("    REM REMIXER \"%s\"\n", track.Field.Rem.Remixer)
-			}
-			if track.Field.Rem.Vocal != "" {
-				output += fmt.Sprintf("    REM VOCAL \"%s\"\n", track.Field.Rem.Vocal)
-			}
-			if track.Field.Rem.BackingVocal != "" {
-				output += fmt.Sprintf("    REM BACKING_VOCAL \"%s\"\n", track.Field.Rem.BackingVocal)
-			}
-			flags := []string{}
-			if track.Field.Flags.DigitalCopyPermitted {
-				flags = append(flags, "DCP")
-			}
-			if track.Field.Flags.FourChannelAudio {
-				flags = append(flags, "4CH")
-			}
-			if track.Field.Flags.PreEmphasisEnabled {
-				flags = append(flags, "PRE")
-			}
-			if track.Field.Flags.SerialCopyManagementSystem {
-				flags = append(flags, "SCMS")
-			}
-			if len(flags) != 0 {
-				output += fmt.Sprintf("    FLAGS %s\n", strings.Join(flags, " "))
-			}
-			if track.Command.SubCommand.Index.Index00 != "" {
-				output += fmt.Sprintf("    INDEX 00 %s\n", track.Command.SubCommand.Index.Index00)
-			}
-			if track.Command.SubCommand.Index.Index01 != "" {
-				output += fmt.Sprintf("    INDEX 01 %s\n", track.Command.SubCommand.Index.Index01)
-			}
-		}
+	for i, readError := range a.Rem.ReadErrors {
+		add(fmt.Sprintf("REM READ_ERROR#%d", i), "REM READ_ERROR "+quote(readError))
 	}
+	for i, rem := range a.Rem.Unknown {
+		add(fmt.Sprintf("REM#%d", i), fmt.Sprintf("REM %s %s", rem.Key, quoteIfNeeded(rem.Value)))
+	}
+	if a.Catalog != "" {
+		add("CATALOG", "CATALOG "+a.Catalog)
+	}
+	if a.CdTextFile != "" {
+		add("CDTEXTFILE", "CDTEXTFILE "+quote(a.CdTextFile))
+	}
+	if a.Title != "" {
+		add("TITLE", "TITLE "+quote(a.Title))
+	}
+	if a.Performer != "" {
+		add("PERFORMER", "PERFORMER "+quote(a.Performer))
+	}
+	if a.Songwriter != "" {
+		add("SONGWRITER", "SONGWRITER "+quote(a.Songwriter))
+	}
+	return entries
+}
 
-	err := os.WriteFile(outputPath, []byte(output), 0644)
-	if err != nil {
-		return err
+// FILEの出力内容
+func (f File) entries() []entry {
+	return []entry{
+		{key: "FILE", text: fmt.Sprintf("FILE %s %s", quote(f.Name), f.Type)},
 	}
-	return nil
+}
+
+// トラックの出力内容
+func (t Track) entries() []entry {
+	entries := []entry{}
+	add := func(key string, text string) {
+		entries = append(entries, entry{key: key, text: "    " + text})
+	}
+	entries = append(entries, entry{key: "TRACK", text: fmt.Sprintf("  TRACK %02d %s", t.Command.Track, conditional.Value(t.Command.DataType != "", t.Command.DataType, TrackTypeAudio))})
+	if t.Command.SubCommand.Isrc != "" {
+		add("ISRC", "ISRC "+t.Command.SubCommand.Isrc)
+	}
+	if t.Field.Title != "" {
+		add("TITLE", "TITLE "+quote(t.Field.Title))
+	}
+	if t.Field.Performer != "" {
+		add("PERFORMER", "PERFORMER "+quote(t.Field.Performer))
+	}
+	if t.Field.Songwriter != "" {
+		add("SONGWRITER", "SONGWRITER "+quote(t.Field.Songwriter))
+	}
+	for i, credit := range t.Field.Credits {
+		add(fmt.Sprintf("CREDIT#%d", i), fmt.Sprintf("REM %s %s", credit.Role, quote(credit.Name)))
+	}
+	for i, rem := range t.Field.Rem.Unknown {
+		add(fmt.Sprintf("REM#%d", i), fmt.Sprintf("REM %s %s", rem.Key, quoteIfNeeded(rem.Value)))
+	}
+	if flags := t.Field.flags(); len(flags) != 0 {
+		add("FLAGS", "FLAGS "+strings.Join(flags, " "))
+	}
+	if t.Command.SubCommand.Pregap != nil {
+		add("PREGAP", "PREGAP "+t.Command.SubCommand.Pregap.String())
+	}
+	for _, index := range t.Command.SubCommand.Indexes {
+		add(fmt.Sprintf("INDEX %02d", index.Number), fmt.Sprintf("INDEX %02d %s", index.Number, index.Position))
+	}
+	if t.Command.SubCommand.Postgap != nil {
+		add("POSTGAP", "POSTGAP "+t.Command.SubCommand.Postgap.String())
+	}
+	return entries
 }
 
 var titleToFileNameReplacer = strings.NewReplacer(
@@ -589,3 +457,8 @@ var titleToFileNameReplacer = strings.NewReplacer(
 func TitleToFileName(title string) string {
 	return titleToFileNameReplacer.Replace(title)
 }
+
+// SplitTrackで分割した際のファイル名
+func TrackFileName(track Track, extension string) string {
+	return fmt.Sprintf("%02d %s%s", track.Command.Track, TitleToFileName(track.Field.Title), extension)
+}
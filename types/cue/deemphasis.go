@@ -0,0 +1,43 @@
+package cue
+
+import (
+	"slices"
+
+	"github.com/ryo-kagawa/Music/types/deemphasis"
+	"github.com/ryo-kagawa/Music/types/wave"
+	"github.com/ryo-kagawa/go-utils/conditional"
+)
+
+// PREフラグが設定されたトラックのみのファイルにディエンファシスを適用する
+// NOTE: CDDA(44.1kHz/16bit/ステレオ)のファイルのみを対象とする
+// NOTE: 適用したファイルは24bitとなり、PREフラグを解除する
+// NOTE: フィルタは出力時に適用する
+// NOTE: ファイル単位で適用するため、SplitTrackで分割した後に使用する
+func (c Cue) Deemphasis() Cue {
+	cue := c
+	cue.Album.Command.Files = slices.Clone(c.Album.Command.Files)
+	for i, file := range cue.Album.Command.Files {
+		format := file.Wave.Format
+		if len(file.Tracks) == 0 || format.FormatTag != wave.FormatPCM || format.SamplingRate != samplingRate || format.Channels != channels || format.BitDepth != 16 {
+			continue
+		}
+		if slices.ContainsFunc(file.Tracks, func(track Track) bool {
+			return !track.Field.Flags.PreEmphasisEnabled
+		}) {
+			continue
+		}
+		file.Audio.Deemphasis = true
+		format = wave.NewPCM(channels, samplingRate, deemphasis.OutputBitDepth)
+		// NOTE: チャンネル配置などの情報は維持する
+		format.Extensible = file.Wave.Format.Extensible
+		format.ValidBits = conditional.Value(format.Extensible, uint16(deemphasis.OutputBitDepth), 0)
+		format.ChannelMask = file.Wave.Format.ChannelMask
+		file.Wave.Format = format
+		file.Tracks = slices.Clone(file.Tracks)
+		for j := range file.Tracks {
+			file.Tracks[j].Field.Flags.PreEmphasisEnabled = false
+		}
+		cue.Album.Command.Files[i] = file
+	}
+	return cue
+}
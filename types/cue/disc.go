@@ -0,0 +1,55 @@
+package cue
+
+import (
+	"fmt"
+
+	"github.com/ryo-kagawa/Music/types/timecode"
+	"github.com/ryo-kagawa/go-utils/conditional"
+)
+
+// CDから読み込んだトラックの情報
+type DiscTrack struct {
+	Number int
+	// ファイル先頭からの位置(セクタ)
+	Start                int
+	PreEmphasisEnabled   bool
+	DigitalCopyPermitted bool
+	FourChannelAudio     bool
+	// データトラック(2352Byteのセクタ全体として読み込む)
+	DataTrack bool
+}
+
+// CDから読み込んだイメージファイルのCUEシートを作成する
+// NOTE: トラック名は「Track NN」とし、後から編集する前提とする
+func FromDisc(fileName string, title string, performer string, tracks []DiscTrack) Cue {
+	cue := Cue{}
+	cue.Album.Field.Title = title
+	cue.Album.Field.Performer = performer
+	file := File{
+		Name: fileName,
+		Type: FileTypeWave,
+	}
+	for _, discTrack := range tracks {
+		track := Track{
+			Command: TrackCommand{
+				Track:    discTrack.Number,
+				DataType: conditional.Value(discTrack.DataTrack, TrackTypeMode1Raw, TrackTypeAudio),
+				SubCommand: TrackSubCommand{
+					Indexes: []Index{
+						{
+							Number:   1,
+							Position: timecode.Timecode(discTrack.Start),
+						},
+					},
+				},
+			},
+		}
+		track.Field.Title = fmt.Sprintf("Track %02d", discTrack.Number)
+		track.Field.Flags.PreEmphasisEnabled = discTrack.PreEmphasisEnabled
+		track.Field.Flags.DigitalCopyPermitted = discTrack.DigitalCopyPermitted
+		track.Field.Flags.FourChannelAudio = discTrack.FourChannelAudio
+		file.Tracks = append(file.Tracks, track)
+	}
+	cue.Album.Command.Files = append(cue.Album.Command.Files, file)
+	return cue
+}
@@ -0,0 +1,395 @@
+package cue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/types/timecode"
+	"github.com/ryo-kagawa/go-utils/conditional"
+	"gopkg.in/yaml.v3"
+)
+
+// JSON・YAMLで入出力するCUEシートの形式の版
+// NOTE: 互換性の無い変更・項目の追加を行う場合に更新する
+// NOTE: 版2で album.discSubtitle・files[].tracks[].type を追加した
+const DocumentVersion = 2
+
+// 読み込める最も古い版
+// NOTE: 版1は版2の項目の一部のみを持つため、そのまま読み込める
+const minimumDocumentVersion = 1
+
+// JSON・YAMLの形式
+const (
+	DocumentJSON = "json"
+	DocumentYAML = "yaml"
+)
+
+// 拡張子に対応するJSON・YAMLの形式
+func DocumentFormat(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return DocumentJSON, true
+	case ".yaml", ".yml":
+		return DocumentYAML, true
+	}
+	return "", false
+}
+
+// JSON・YAMLで入出力するCUEシート
+// NOTE: 時間は「分:秒:フレーム」の文字列とする
+type Document struct {
+	// 形式の版(DocumentVersion)
+	Version int            `json:"version" yaml:"version"`
+	Album   DocumentAlbum  `json:"album" yaml:"album"`
+	Files   []DocumentFile `json:"files" yaml:"files"`
+}
+
+type DocumentAlbum struct {
+	Title        string        `json:"title,omitempty" yaml:"title,omitempty"`
+	Performer    string        `json:"performer,omitempty" yaml:"performer,omitempty"`
+	Songwriter   string        `json:"songwriter,omitempty" yaml:"songwriter,omitempty"`
+	Catalog      string        `json:"catalog,omitempty" yaml:"catalog,omitempty"`
+	CdTextFile   string        `json:"cdTextFile,omitempty" yaml:"cdTextFile,omitempty"`
+	Genre        string        `json:"genre,omitempty" yaml:"genre,omitempty"`
+	Date         string        `json:"date,omitempty" yaml:"date,omitempty"`
+	Publisher    string        `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	Label        string        `json:"label,omitempty" yaml:"label,omitempty"`
+	Producer     string        `json:"producer,omitempty" yaml:"producer,omitempty"`
+	Production   string        `json:"production,omitempty" yaml:"production,omitempty"`
+	Work         string        `json:"work,omitempty" yaml:"work,omitempty"`
+	BGMWork      string        `json:"bgmWork,omitempty" yaml:"bgmWork,omitempty"`
+	BGMDirector  string        `json:"bgmDirector,omitempty" yaml:"bgmDirector,omitempty"`
+	Composer     string        `json:"composer,omitempty" yaml:"composer,omitempty"`
+	DiscNumber   string        `json:"discNumber,omitempty" yaml:"discNumber,omitempty"`
+	TotalDiscs   string        `json:"totalDiscs,omitempty" yaml:"totalDiscs,omitempty"`
+	DiscSubtitle string        `json:"discSubtitle,omitempty" yaml:"discSubtitle,omitempty"`
+	DiscId       string        `json:"discId,omitempty" yaml:"discId,omitempty"`
+	Jan          string        `json:"jan,omitempty" yaml:"jan,omitempty"`
+	Comment      string        `json:"comment,omitempty" yaml:"comment,omitempty"`
+	ReadErrors   []string      `json:"readErrors,omitempty" yaml:"readErrors,omitempty"`
+	Rem          []DocumentRem `json:"rem,omitempty" yaml:"rem,omitempty"`
+}
+
+type DocumentFile struct {
+	Name string `json:"name" yaml:"name"`
+	// WAVE・FLAC・AIFF・BINARY・MOTOROLA・MP3
+	Type   string          `json:"type" yaml:"type"`
+	Tracks []DocumentTrack `json:"tracks" yaml:"tracks"`
+}
+
+type DocumentTrack struct {
+	Number int `json:"number" yaml:"number"`
+	// TRACKの種類(AUDIOの場合は省略する)
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"`
+	Title      string `json:"title,omitempty" yaml:"title,omitempty"`
+	Performer  string `json:"performer,omitempty" yaml:"performer,omitempty"`
+	Songwriter string `json:"songwriter,omitempty" yaml:"songwriter,omitempty"`
+	Isrc       string `json:"isrc,omitempty" yaml:"isrc,omitempty"`
+	// DCP・4CH・PRE・SCMS
+	Flags   []string         `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Pregap  string           `json:"pregap,omitempty" yaml:"pregap,omitempty"`
+	Postgap string           `json:"postgap,omitempty" yaml:"postgap,omitempty"`
+	Indexes []DocumentIndex  `json:"indexes" yaml:"indexes"`
+	Credits []DocumentCredit `json:"credits,omitempty" yaml:"credits,omitempty"`
+	Rem     []DocumentRem    `json:"rem,omitempty" yaml:"rem,omitempty"`
+}
+
+type DocumentIndex struct {
+	Number   int    `json:"number" yaml:"number"`
+	Position string `json:"position" yaml:"position"`
+}
+
+type DocumentCredit struct {
+	// REMのキー(COMPOSER・GUITARなど)
+	Role string `json:"role" yaml:"role"`
+	Name string `json:"name" yaml:"name"`
+}
+
+type DocumentRem struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// JSON・YAMLで入出力する形式に変換する
+func (c Cue) Document() Document {
+	album := c.Album.Field
+	document := Document{
+		Version: DocumentVersion,
+		Album: DocumentAlbum{
+			Title:        album.Title,
+			Performer:    album.Performer,
+			Songwriter:   album.Songwriter,
+			Catalog:      album.Catalog,
+			CdTextFile:   album.CdTextFile,
+			Genre:        album.Rem.Genre,
+			Date:         album.Rem.Date,
+			Publisher:    album.Rem.Publisher,
+			Label:        album.Rem.Label,
+			Producer:     album.Rem.Producer,
+			Production:   album.Rem.Production,
+			Work:         album.Rem.Work,
+			BGMWork:      album.Rem.BGMWork,
+			BGMDirector:  album.Rem.BGMDirector,
+			Composer:     album.Rem.Composer,
+			DiscNumber:   album.Rem.DiscNumber,
+			TotalDiscs:   album.Rem.TotalDiscs,
+			DiscSubtitle: album.Rem.DiscSubtitle,
+			DiscId:       album.Rem.DiscId,
+			Jan:          album.Rem.Jan,
+			Comment:      album.Rem.Comment,
+			ReadErrors:   album.Rem.ReadErrors,
+			Rem:          documentRems(album.Rem.Unknown),
+		},
+		Files: []DocumentFile{},
+	}
+	for _, file := range c.Album.Command.Files {
+		documentFile := DocumentFile{
+			Name:   file.Name,
+			Type:   file.Type,
+			Tracks: []DocumentTrack{},
+		}
+		for _, track := range file.Tracks {
+			documentTrack := DocumentTrack{
+				Number:     track.Command.Track,
+				Title:      track.Field.Title,
+				Performer:  track.Field.Performer,
+				Songwriter: track.Field.Songwriter,
+				Type:       conditional.Value(track.IsAudio(), "", track.Command.DataType),
+				Isrc:       track.Command.SubCommand.Isrc,
+				Flags:      track.Field.flags(),
+				Indexes:    []DocumentIndex{},
+				Rem:        documentRems(track.Field.Rem.Unknown),
+			}
+			if track.Command.SubCommand.Pregap != nil {
+				documentTrack.Pregap = track.Command.SubCommand.Pregap.String()
+			}
+			if track.Command.SubCommand.Postgap != nil {
+				documentTrack.Postgap = track.Command.SubCommand.Postgap.String()
+			}
+			for _, index := range track.Command.SubCommand.Indexes {
+				documentTrack.Indexes = append(documentTrack.Indexes, DocumentIndex{
+					Number:   index.Number,
+					Position: index.Position.String(),
+				})
+			}
+			for _, credit := range track.Field.Credits {
+				documentTrack.Credits = append(documentTrack.Credits, DocumentCredit{
+					Role: credit.Role,
+					Name: credit.Name,
+				})
+			}
+			documentFile.Tracks = append(documentFile.Tracks, documentTrack)
+		}
+		document.Files = append(document.Files, documentFile)
+	}
+	return document
+}
+
+func documentRems(rems []Rem) []DocumentRem {
+	var result []DocumentRem
+	for _, rem := range rems {
+		result = append(result, DocumentRem{Key: rem.Key, Value: rem.Value})
+	}
+	return result
+}
+
+// CUEシートに変換する
+// NOTE: 音声データは読み込まない
+func (d Document) Cue() (Cue, error) {
+	if d.Version < minimumDocumentVersion || DocumentVersion < d.Version {
+		return Cue{}, fmt.Errorf("形式の版%dに未対応です(対応している版: %dから%d)", d.Version, minimumDocumentVersion, DocumentVersion)
+	}
+	cue := Cue{}
+	album := &cue.Album.Field
+	album.Title = d.Album.Title
+	album.Performer = d.Album.Performer
+	album.Songwriter = d.Album.Songwriter
+	album.Catalog = d.Album.Catalog
+	album.CdTextFile = d.Album.CdTextFile
+	album.Rem.Genre = d.Album.Genre
+	album.Rem.Date = d.Album.Date
+	album.Rem.Publisher = d.Album.Publisher
+	album.Rem.Label = d.Album.Label
+	album.Rem.Producer = d.Album.Producer
+	album.Rem.Production = d.Album.Production
+	album.Rem.Work = d.Album.Work
+	album.Rem.BGMWork = d.Album.BGMWork
+	album.Rem.BGMDirector = d.Album.BGMDirector
+	album.Rem.Composer = d.Album.Composer
+	album.Rem.DiscNumber = d.Album.DiscNumber
+	album.Rem.TotalDiscs = d.Album.TotalDiscs
+	album.Rem.DiscSubtitle = d.Album.DiscSubtitle
+	album.Rem.DiscId = d.Album.DiscId
+	album.Rem.Jan = d.Album.Jan
+	album.Rem.Comment = d.Album.Comment
+	album.Rem.ReadErrors = slices.Clone(d.Album.ReadErrors)
+	album.Rem.Unknown = rems(d.Album.Rem)
+	for i, documentFile := range d.Files {
+		fileType := strings.ToUpper(documentFile.Type)
+		if !isFileType(fileType) {
+			return Cue{}, fmt.Errorf("files[%d]: FILEの種類\"%s\"に未対応です", i, documentFile.Type)
+		}
+		file := File{
+			Name: documentFile.Name,
+			Type: fileType,
+		}
+		for j, documentTrack := range documentFile.Tracks {
+			track, err := documentTrack.track()
+			if err != nil {
+				return Cue{}, fmt.Errorf("files[%d].tracks[%d]: %w", i, j, err)
+			}
+			file.Tracks = append(file.Tracks, track)
+		}
+		cue.Album.Command.Files = append(cue.Album.Command.Files, file)
+	}
+	return cue, nil
+}
+
+func (d DocumentTrack) track() (Track, error) {
+	if d.Number < 1 || 99 < d.Number {
+		return Track{}, fmt.Errorf("トラック番号%dが不正です(1から99)", d.Number)
+	}
+	track := Track{}
+	track.Command.Track = d.Number
+	if d.Type != "" {
+		dataType := strings.ToUpper(d.Type)
+		if !isTrackType(dataType) {
+			return Track{}, fmt.Errorf("TRACKの種類\"%s\"に未対応です", d.Type)
+		}
+		track.Command.DataType = dataType
+	}
+	track.Command.SubCommand.Isrc = d.Isrc
+	track.Field.Title = d.Title
+	track.Field.Performer = d.Performer
+	track.Field.Songwriter = d.Songwriter
+	for _, flag := range d.Flags {
+		switch strings.ToUpper(flag) {
+		case "DCP":
+			track.Field.Flags.DigitalCopyPermitted = true
+		case "4CH":
+			track.Field.Flags.FourChannelAudio = true
+		case "PRE":
+			track.Field.Flags.PreEmphasisEnabled = true
+		case "SCMS":
+			track.Field.Flags.SerialCopyManagementSystem = true
+		default:
+			return Track{}, fmt.Errorf("FLAGSの\"%s\"に未対応です", flag)
+		}
+	}
+	if d.Pregap != "" {
+		pregap, err := timecode.Parse(d.Pregap)
+		if err != nil {
+			return Track{}, fmt.Errorf("pregap: %w", err)
+		}
+		track.Command.SubCommand.Pregap = &pregap
+	}
+	if d.Postgap != "" {
+		postgap, err := timecode.Parse(d.Postgap)
+		if err != nil {
+			return Track{}, fmt.Errorf("postgap: %w", err)
+		}
+		track.Command.SubCommand.Postgap = &postgap
+	}
+	for i, documentIndex := range d.Indexes {
+		if documentIndex.Number < 0 || 99 < documentIndex.Number {
+			return Track{}, fmt.Errorf("indexes[%d]: インデックス番号%dが不正です(0から99)", i, documentIndex.Number)
+		}
+		position, err := timecode.Parse(documentIndex.Position)
+		if err != nil {
+			return Track{}, fmt.Errorf("indexes[%d]: %w", i, err)
+		}
+		track.Command.SubCommand.Indexes = append(track.Command.SubCommand.Indexes, Index{
+			Number:   documentIndex.Number,
+			Position: position,
+		})
+	}
+	if _, ok := track.Command.SubCommand.Index(1); !ok {
+		return Track{}, fmt.Errorf("INDEX 01がありません")
+	}
+	for _, credit := range d.Credits {
+		track.Field.Credits = append(track.Field.Credits, Credit{
+			Role: strings.ToUpper(credit.Role),
+			Name: credit.Name,
+		})
+	}
+	track.Field.Rem.Unknown = rems(d.Rem)
+	return track, nil
+}
+
+func rems(documentRems []DocumentRem) []Rem {
+	var result []Rem
+	for _, rem := range documentRems {
+		result = append(result, Rem{Key: rem.Key, Value: rem.Value})
+	}
+	return result
+}
+
+// FLAGSの値
+func (t TrackField) flags() []string {
+	flags := []string{}
+	if t.Flags.DigitalCopyPermitted {
+		flags = append(flags, "DCP")
+	}
+	if t.Flags.FourChannelAudio {
+		flags = append(flags, "4CH")
+	}
+	if t.Flags.PreEmphasisEnabled {
+		flags = append(flags, "PRE")
+	}
+	if t.Flags.SerialCopyManagementSystem {
+		flags = append(flags, "SCMS")
+	}
+	return flags
+}
+
+// JSON・YAMLとして出力する
+func (c Cue) MarshalDocument(format string) ([]byte, error) {
+	document := c.Document()
+	switch format {
+	case DocumentJSON:
+		binary, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(binary, '\n'), nil
+	case DocumentYAML:
+		var buffer bytes.Buffer
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}
+	return nil, fmt.Errorf("形式\"%s\"に未対応です(json/yaml)", format)
+}
+
+// JSON・YAMLを読み込む
+// NOTE: 未知の項目は誤りとする
+func UnmarshalDocument(binary []byte, format string) (Cue, error) {
+	document := Document{}
+	switch format {
+	case DocumentJSON:
+		decoder := json.NewDecoder(bytes.NewReader(binary))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&document); err != nil {
+			return Cue{}, err
+		}
+	case DocumentYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(binary))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&document); err != nil {
+			return Cue{}, err
+		}
+	default:
+		return Cue{}, fmt.Errorf("形式\"%s\"に未対応です(json/yaml)", format)
+	}
+	return document.Cue()
+}
@@ -0,0 +1,126 @@
+package cue
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ryo-kagawa/Music/types/aiff"
+	"github.com/ryo-kagawa/Music/types/wave"
+)
+
+// FILEの種類
+const (
+	FileTypeWave = "WAVE"
+	FileTypeFlac = "FLAC"
+	FileTypeAiff = "AIFF"
+	// ヘッダーの無いCDDA形式(リトルエンディアン)
+	FileTypeBinary = "BINARY"
+	// ヘッダーの無いCDDA形式(ビッグエンディアン)
+	FileTypeMotorola = "MOTOROLA"
+	FileTypeMp3      = "MP3"
+)
+
+func isFileType(fileType string) bool {
+	switch fileType {
+	case FileTypeWave, FileTypeFlac, FileTypeAiff, FileTypeBinary, FileTypeMotorola, FileTypeMp3:
+		return true
+	}
+	return false
+}
+
+// 音声データを読み込めるFILEの種類
+// NOTE: FLAC・MP3はデコードしたWAVEファイルをWithDecodedWaveで設定する
+func canReadAudio(fileType string) bool {
+	switch fileType {
+	case FileTypeWave, FileTypeAiff, FileTypeBinary, FileTypeMotorola:
+		return true
+	}
+	return false
+}
+
+// FILEの種類に従って音声データの形式と位置を読み込む
+func readAudio(path string, fileType string) (wave.Wave, Audio, error) {
+	switch fileType {
+	case FileTypeWave:
+		waveInfo, err := readWave(path)
+		if err != nil {
+			return wave.Wave{}, Audio{}, err
+		}
+		return waveInfo, Audio{
+			Path:   path,
+			Offset: waveInfo.DataOffset,
+			Size:   waveInfo.DataSize,
+		}, nil
+	case FileTypeAiff:
+		aiffInfo, err := readAiff(path)
+		if err != nil {
+			return wave.Wave{}, Audio{}, err
+		}
+		audio := Audio{
+			Path:   path,
+			Offset: aiffInfo.DataOffset,
+			Size:   aiffInfo.DataSize,
+		}
+		if aiffInfo.BigEndian {
+			audio.SwapBytes = int(aiffInfo.Format.BitDepth / 8)
+		}
+		return wave.New(aiffInfo.Format), audio, nil
+	case FileTypeBinary, FileTypeMotorola:
+		info, err := os.Stat(path)
+		if err != nil {
+			return wave.Wave{}, Audio{}, err
+		}
+		audio := Audio{
+			Path: path,
+			Size: info.Size(),
+		}
+		if fileType == FileTypeMotorola {
+			audio.SwapBytes = bitDepth
+		}
+		return wave.New(wave.CDDA()), audio, nil
+	}
+	return wave.Wave{}, Audio{}, fmt.Errorf("FILEの種類%sの音声データは読み込めません", fileType)
+}
+
+func readAiff(path string) (aiff.Aiff, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return aiff.Aiff{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return aiff.Aiff{}, err
+	}
+	return aiff.Read(file, info.Size())
+}
+
+// 音声データを読み込めている
+func (f File) HasAudio() bool {
+	return f.Audio.Path != ""
+}
+
+// FLAC・MP3などをデコードしたWAVEファイルを音声データとして設定する
+func (f File) WithDecodedWave(wavePath string) (File, error) {
+	waveInfo, audio, err := readAudio(wavePath, FileTypeWave)
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	if err := validateFormat(waveInfo.Format); err != nil {
+		return File{}, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	f.Wave = waveInfo
+	f.Audio = audio
+	return f, nil
+}
+
+// 全てのファイルの音声データを読み込めていることを確認する
+// NOTE: 分割・出力など音声データを使用する処理の前に使用する
+func (c Cue) RequireAudio() error {
+	for _, file := range c.Album.Command.Files {
+		if !file.HasAudio() {
+			return fmt.Errorf("%s: FILEの種類%sの音声データは読み込めません", file.Name, file.Type)
+		}
+	}
+	return nil
+}
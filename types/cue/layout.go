@@ -0,0 +1,149 @@
+package cue
+
+import (
+	"slices"
+	"strings"
+)
+
+// 読み込んだCUEシートの行
+// NOTE: 出力時に元の順序と表記を維持するために使用する
+type Line struct {
+	// 読み込んだ内容をそのまま保持する
+	Raw string
+	// 対応するフィールドの識別子(未対応の行・空行は空)
+	Key string
+	// 読み込み時のフィールドの出力内容
+	// NOTE: 出力時の内容と一致する場合は変更されていないとしてRawを出力する
+	Text string
+	// 読み込み時に値を修正した
+	// NOTE: 出力時は元の表記を使用せずに出力内容で書き換える
+	dirty bool
+}
+
+// 読み込んだCUEシートの書式
+type Layout struct {
+	// 改行コード(未指定の場合は"\n")
+	LineEnding string
+	// 最終行に改行が無い
+	NoTrailingNewline bool
+	// 先頭にBOMがある
+	BOM bool
+	// 読み込み時の文字コード(utils.EncodingShiftJISなど、未指定の場合はUTF-8)
+	Encoding string
+}
+
+// フィールドの出力内容
+type entry struct {
+	key  string
+	text string
+}
+
+// 値を「"」で囲む
+// NOTE: 値の「"」は「""」とする
+func quote(value string) string {
+	return "\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\""
+}
+
+// 空白・「"」を含む場合や空の場合のみ値を「"」で囲む
+func quoteIfNeeded(value string) string {
+	if value == "" || strings.ContainsFunc(value, func(r rune) bool {
+		return isSpace(r) || r == '"'
+	}) {
+		return quote(value)
+	}
+	return value
+}
+
+// 読み込み時の行とフィールドの出力内容を合わせて出力する行を求める
+// NOTE: 変更の無いフィールドは元の行を、変更されたフィールドは出力内容を元の位置に出力する
+// NOTE: 削除されたフィールドの行は出力しない
+// NOTE: 追加されたフィールドは出力内容の順序で後に続くフィールドの行の前に出力する
+// NOTE: 未対応の行・空行はそのまま出力する
+func merge(lines []Line, entries []entry) []string {
+	order := map[string]int{}
+	for i, e := range entries {
+		order[e.key] = i
+	}
+	recorded := map[string]bool{}
+	for _, line := range lines {
+		if _, ok := order[line.Key]; ok {
+			recorded[line.Key] = true
+		}
+	}
+	output := []string{}
+	next := 0
+	// 指定した順序より前の追加されたフィールドを出力する
+	insert := func(limit int) {
+		for ; next < limit; next++ {
+			if !recorded[entries[next].key] {
+				output = append(output, entries[next].text)
+			}
+		}
+	}
+	for _, line := range lines {
+		if line.Key == "" {
+			output = append(output, line.Raw)
+			continue
+		}
+		i, ok := order[line.Key]
+		if !ok {
+			continue
+		}
+		insert(i)
+		if entries[i].text == line.Text {
+			output = append(output, line.Raw)
+		} else {
+			output = append(output, entries[i].text)
+		}
+	}
+	insert(len(entries))
+	return output
+}
+
+// 読み込み時の行にフィールドの出力内容を記録する
+// NOTE: 出力内容の無いフィールドの行は未対応の行として扱う
+func record(lines []Line, entries []entry) {
+	text := map[string]string{}
+	for _, e := range entries {
+		text[e.key] = e.text
+	}
+	for i := range lines {
+		if lines[i].Key == "" {
+			continue
+		}
+		value, ok := text[lines[i].Key]
+		if !ok {
+			lines[i].Key = ""
+			continue
+		}
+		if lines[i].dirty {
+			continue
+		}
+		lines[i].Text = value
+	}
+}
+
+// 読み込み時の行と書式を引き継ぐ
+// NOTE: ファイル・トラックは同じ位置のものから引き継ぐ
+func (c Cue) WithLayout(base Cue) Cue {
+	cue := c
+	cue.Layout = base.Layout
+	cue.Album.Lines = base.Album.Lines
+	cue.Album.Command.Files = slices.Clone(c.Album.Command.Files)
+	for i := range cue.Album.Command.Files {
+		if len(base.Album.Command.Files) <= i {
+			break
+		}
+		file := &cue.Album.Command.Files[i]
+		baseFile := base.Album.Command.Files[i]
+		file.Lines = baseFile.Lines
+		file.Tracks = slices.Clone(file.Tracks)
+		for j := range file.Tracks {
+			if len(baseFile.Tracks) <= j {
+				break
+			}
+			file.Tracks[j].Lines = baseFile.Tracks[j].Lines
+		}
+	}
+	return cue
+}
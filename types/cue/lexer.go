@@ -0,0 +1,90 @@
+package cue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CUEシートの1行を区切った要素
+type token struct {
+	Value string
+	// 行頭を1とした位置(文字単位)
+	Column int
+	// 「"」で囲まれていた
+	Quoted bool
+}
+
+// CUEシートの読み込み時の誤り
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	// 読み込みを継続できる誤りか(寛容モードでは警告として扱う)
+	Warning bool
+	// 厳格モードでは読み込みを中断する誤りか
+	// NOTE: 囲まれていない値の連結のように厳格モードでも受け付ける場合は寛容モードで注意として記録する
+	Fatal   bool
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\uFEFF'
+}
+
+// 1行を空白区切りの要素に分割する
+// NOTE: 「"」で囲まれた範囲は空白を含めて1つの要素とし、範囲内の「""」は「"」として扱う
+// NOTE: CUEシートにはエスケープが無く「"C:\music\"」のようにWindowsのパスが「\」で終わるため、「\」は文字としてそのまま扱う
+func tokenize(line string) ([]token, int, error) {
+	runes := []rune(line)
+	tokens := []token{}
+	for i := 0; i < len(runes); {
+		if isSpace(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		if runes[i] != '"' {
+			for i < len(runes) && !isSpace(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{Value: string(runes[start:i]), Column: start + 1})
+			continue
+		}
+		var builder strings.Builder
+		closed := false
+		for i++; i < len(runes); i++ {
+			switch {
+			case runes[i] == '"' && i+1 < len(runes) && runes[i+1] == '"':
+				builder.WriteRune('"')
+				i++
+			case runes[i] == '"':
+				closed = true
+			default:
+				builder.WriteRune(runes[i])
+			}
+			if closed {
+				i++
+				break
+			}
+		}
+		if !closed {
+			return nil, start + 1, fmt.Errorf("「\"」が閉じられていません")
+		}
+		tokens = append(tokens, token{Value: builder.String(), Column: start + 1, Quoted: true})
+	}
+	return tokens, 0, nil
+}
+
+// 要素の値を空白区切りで連結する
+// NOTE: 「REM COMMENT foo bar」のように囲まれていない値に対応する
+func joinTokens(tokens []token) string {
+	values := []string{}
+	for _, t := range tokens {
+		values = append(values, t.Value)
+	}
+	return strings.Join(values, " ")
+}
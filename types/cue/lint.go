@@ -0,0 +1,207 @@
+package cue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/types/timecode"
+)
+
+// 検査結果の重要度
+type Severity string
+
+const (
+	// CUEシートの仕様に反する
+	SeverityError Severity = "error"
+	// 再生・書き込みで問題になりうる
+	SeverityWarning Severity = "warning"
+	// Fixで修正した
+	SeverityFixed Severity = "fixed"
+)
+
+// 検査結果
+type Finding struct {
+	Severity Severity
+	// 対象の行(「TRACK 01」など)
+	Location string
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Location, f.Message)
+}
+
+// Red Bookのトラックの最短の長さ(4秒)
+const minimumTrackLength = 4 * timecode.FramesPerSecond
+
+// 国コード(2文字)・登録者コード(3文字)・年(2桁)・番号(5桁)
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// UPC/EAN(13桁)
+var catalogPattern = regexp.MustCompile(`^[0-9]{13}$`)
+
+// 読み込んだCUEシートの内容を検査する
+// NOTE: INDEXの順序・時間の形式などの構文の誤りはLoadLenientの警告として検出する
+func (c Cue) Lint(cueDirectory string) []Finding {
+	findings := []Finding{}
+	add := func(severity Severity, location string, format string, arguments ...any) {
+		findings = append(findings, Finding{
+			Severity: severity,
+			Location: location,
+			Message:  fmt.Sprintf(format, arguments...),
+		})
+	}
+	if c.Album.Field.Catalog != "" {
+		switch {
+		case !catalogPattern.MatchString(c.Album.Field.Catalog):
+			add(SeverityError, "CATALOG", "\"%s\"は13桁の数字ではありません", c.Album.Field.Catalog)
+		case !validCheckDigit(c.Album.Field.Catalog):
+			add(SeverityWarning, "CATALOG", "\"%s\"のチェックデジットが一致しません", c.Album.Field.Catalog)
+		}
+	}
+	previousTrack := 0
+	for _, file := range c.Album.Command.Files {
+		fileLocation := fmt.Sprintf("FILE %s", file.Name)
+		// NOTE: 音声データを読み込むFILEの種類はLoadLenientで検出する
+		if !canReadAudio(file.Type) {
+			if _, err := os.Stat(filepath.Join(cueDirectory, file.Name)); err != nil {
+				add(SeverityError, fileLocation, "ファイルが存在しません")
+			}
+		}
+		// NOTE: 音声データを読み込めない場合はファイルの長さに関する検査を行わない
+		var end *timecode.Timecode
+		if file.HasAudio() && file.Wave.Format.BlockAlign != 0 {
+			samples := int(file.Audio.Size) / int(file.Wave.Format.BlockAlign)
+			position := timecode.Timecode(samples * timecode.FramesPerSecond / int(file.Wave.Format.SamplingRate))
+			end = &position
+		}
+		for i, track := range file.Tracks {
+			trackLocation := fmt.Sprintf("TRACK %02d", track.Command.Track)
+			// NOTE: トラック番号は01から始まる連番とする
+			switch {
+			case previousTrack == 0 && track.Command.Track != 1:
+				add(SeverityError, trackLocation, "最初のトラック番号が01ではありません")
+			case previousTrack != 0 && track.Command.Track != previousTrack+1:
+				add(SeverityError, trackLocation, "トラック番号がTRACK %02dの次の番号ではありません", previousTrack)
+			}
+			previousTrack = track.Command.Track
+			if isrc := track.Command.SubCommand.Isrc; isrc != "" && !isrcPattern.MatchString(isrc) {
+				add(SeverityError, trackLocation, "ISRC\"%s\"の形式が不正です(CCXXXYYNNNNN)", isrc)
+			}
+			if end != nil {
+				for _, index := range track.Command.SubCommand.Indexes {
+					if *end < index.Position {
+						add(SeverityError, trackLocation, "INDEX %02d %sがファイルの長さ%sを超えています", index.Number, index.Position, *end)
+					}
+				}
+			}
+			var trackEnd *timecode.Timecode
+			if i != len(file.Tracks)-1 {
+				position := file.Tracks[i+1].Command.SubCommand.GapStart()
+				trackEnd = &position
+			} else {
+				trackEnd = end
+			}
+			if trackEnd != nil {
+				length := trackEnd.Sub(track.Command.SubCommand.Start())
+				if 0 <= length && length < minimumTrackLength {
+					add(SeverityWarning, trackLocation, "トラックの長さ%sが4秒未満です", length)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// EAN-13のチェックデジットを確認する
+func validCheckDigit(value string) bool {
+	sum := 0
+	for i, r := range value[:12] {
+		digit := int(r - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(value[12]-'0')
+}
+
+// 安全に修正できる誤りを修正する
+// NOTE: ISRC・CATALOGの区切り文字・小文字と、拡張子のみ異なるファイル名を修正する
+// NOTE: LoadLenientで警告となった行は出力時に書き換える
+func (c Cue) Fix(cueDirectory string) (Cue, []Finding) {
+	fixed := []Finding{}
+	add := func(location string, format string, arguments ...any) {
+		fixed = append(fixed, Finding{
+			Severity: SeverityFixed,
+			Location: location,
+			Message:  fmt.Sprintf(format, arguments...),
+		})
+	}
+	cue := c
+	if catalog := c.Album.Field.Catalog; catalog != "" && !catalogPattern.MatchString(catalog) {
+		normalized := strings.NewReplacer("-", "", " ", "").Replace(catalog)
+		if catalogPattern.MatchString(normalized) {
+			cue.Album.Field.Catalog = normalized
+			add("CATALOG", "\"%s\"を\"%s\"に修正しました", catalog, normalized)
+		}
+	}
+	cue.Album.Command.Files = make([]File, len(c.Album.Command.Files))
+	for i, file := range c.Album.Command.Files {
+		if name, fileType, ok := findRenamedFile(cueDirectory, file); ok {
+			add(fmt.Sprintf("FILE %s", file.Name), "\"%s\" %sに修正しました", name, fileType)
+			file.Name = name
+			file.Type = fileType
+		}
+		file.Tracks = make([]Track, len(c.Album.Command.Files[i].Tracks))
+		for j, track := range c.Album.Command.Files[i].Tracks {
+			if isrc := track.Command.SubCommand.Isrc; isrc != "" && !isrcPattern.MatchString(isrc) {
+				normalized := strings.ToUpper(strings.ReplaceAll(isrc, "-", ""))
+				if isrcPattern.MatchString(normalized) {
+					track.Command.SubCommand.Isrc = normalized
+					add(fmt.Sprintf("TRACK %02d", track.Command.Track), "ISRC\"%s\"を\"%s\"に修正しました", isrc, normalized)
+				}
+			}
+			file.Tracks[j] = track
+		}
+		cue.Album.Command.Files[i] = file
+	}
+	return cue, fixed
+}
+
+// 拡張子に対応するFILEの種類
+var extensionFileTypes = []struct {
+	extension string
+	fileType  string
+}{
+	{".wav", FileTypeWave},
+	{".flac", FileTypeFlac},
+	{".aif", FileTypeAiff},
+	{".aiff", FileTypeAiff},
+	{".bin", FileTypeBinary},
+	{".mp3", FileTypeMp3},
+}
+
+// 存在しないファイルについて、拡張子のみ異なるファイルが1つだけ存在する場合にそのファイル名と種類を返す
+func findRenamedFile(cueDirectory string, file File) (string, string, bool) {
+	if _, err := os.Stat(filepath.Join(cueDirectory, file.Name)); err == nil {
+		return "", "", false
+	}
+	stem := strings.TrimSuffix(file.Name, filepath.Ext(file.Name))
+	name := ""
+	fileType := ""
+	for _, candidate := range extensionFileTypes {
+		if _, err := os.Stat(filepath.Join(cueDirectory, stem+candidate.extension)); err != nil {
+			continue
+		}
+		if name != "" {
+			return "", "", false
+		}
+		name = stem + candidate.extension
+		fileType = candidate.fileType
+	}
+	return name, fileType, name != ""
+}
@@ -0,0 +1,566 @@
+package cue
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/types/timecode"
+	"github.com/ryo-kagawa/Music/utils"
+)
+
+// CUEシートを読み込む
+// NOTE: 最初の誤りで読み込みを中断する
+func Load(cueFilepath string) (Cue, error) {
+	cue, _, err := load(cueFilepath, false)
+	return cue, err
+}
+
+// 未対応の行などを警告として読み飛ばしながらCUEシートを読み込む
+// NOTE: 構文の誤りなど読み込みを継続できない場合はエラーを返す
+func LoadLenient(cueFilepath string) (Cue, []*ParseError, error) {
+	return load(cueFilepath, true)
+}
+
+func load(cueFilepath string, lenient bool) (Cue, []*ParseError, error) {
+	cueData, encodingName, err := utils.ReadTextFile(cueFilepath)
+	if err != nil {
+		return Cue{}, nil, err
+	}
+	p := parser{
+		path:    cueFilepath,
+		lenient: lenient,
+	}
+	// NOTE: 変更せずに出力した場合に元の文字コードのままとなるよう記録する
+	p.cue.Layout.Encoding = encodingName
+	if err := p.parse(cueData); err != nil {
+		return Cue{}, p.warnings, err
+	}
+	return p.cue, p.warnings, nil
+}
+
+type parser struct {
+	path     string
+	lenient  bool
+	warnings []*ParseError
+	cue      Cue
+	line     int
+	// FILEより前のアルバムフィールドを読み込み中
+	albumField   bool
+	currentFile  *File
+	currentTrack *Track
+	// 読み込み中のトラックのTRACKの位置
+	trackLine   int
+	trackColumn int
+	// 読み込み中のファイルで最後に読み込んだINDEXの位置
+	lastPosition timecode.Timecode
+	// 読み込んだ行に対応するフィールドの識別子
+	key string
+	// 読み込んだ行の値を修正した
+	dirty bool
+}
+
+func (p *parser) error(column int, format string, arguments ...any) *ParseError {
+	return &ParseError{
+		File:    p.path,
+		Line:    p.line,
+		Column:  column,
+		Fatal:   true,
+		Message: fmt.Sprintf(format, arguments...),
+	}
+}
+
+// 読み込みを継続できる誤り
+// NOTE: 寛容モードでは警告として記録し、それ以外ではエラーとする
+func (p *parser) warn(column int, format string, arguments ...any) error {
+	err := p.error(column, format, arguments...)
+	if !p.lenient {
+		return err
+	}
+	err.Warning = true
+	p.warnings = append(p.warnings, err)
+	return nil
+}
+
+// 厳格モードでも読み込みを継続する注意
+// NOTE: 寛容モードでのみ警告として記録する
+func (p *parser) notice(column int, format string, arguments ...any) {
+	if !p.lenient {
+		return
+	}
+	err := p.error(column, format, arguments...)
+	err.Warning = true
+	err.Fatal = false
+	p.warnings = append(p.warnings, err)
+}
+
+// 時間を解釈する
+// NOTE: 範囲外の秒・フレームは寛容モードでは警告として繰り上げる
+func (p *parser) timecode(t token) (timecode.Timecode, error) {
+	position, err := timecode.Parse(t.Value)
+	if err == nil {
+		return position, nil
+	}
+	position, lenientErr := timecode.ParseLenient(t.Value)
+	if lenientErr != nil {
+		return 0, p.error(t.Column, "%v", err)
+	}
+	if err := p.warn(t.Column, "%v", err); err != nil {
+		return 0, err
+	}
+	p.dirty = true
+	return position, nil
+}
+
+func (p *parser) parse(cueData string) error {
+	p.albumField = true
+	if strings.HasPrefix(cueData, "\uFEFF") {
+		cueData = strings.TrimPrefix(cueData, "\uFEFF")
+		p.cue.Layout.BOM = true
+	}
+	if strings.Contains(cueData, "\r\n") {
+		p.cue.Layout.LineEnding = "\r\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(cueData, "\r\n", "\n"), "\n")
+	// NOTE: 最終行の改行の後の空の要素は行として扱わない
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		p.cue.Layout.NoTrailingNewline = true
+	}
+	for i, line := range lines {
+		p.line = i + 1
+		tokens, column, err := tokenize(line)
+		if err != nil {
+			return p.error(column, "%v", err)
+		}
+		p.key = ""
+		p.dirty = false
+		if len(tokens) != 0 {
+			if err := p.parseLine(tokens); err != nil {
+				return err
+			}
+		}
+		p.appendLine(line)
+	}
+	if err := p.finishTrack(); err != nil {
+		return err
+	}
+	p.record()
+	return nil
+}
+
+// 読み込んだ行を読み込み中の範囲に追加する
+// NOTE: 同じフィールドが複数ある場合は後の行を有効とし、前の行は未対応の行として扱う
+func (p *parser) appendLine(raw string) {
+	lines := &p.cue.Album.Lines
+	if p.currentTrack != nil {
+		lines = &p.currentTrack.Lines
+	} else if p.currentFile != nil {
+		lines = &p.currentFile.Lines
+	}
+	if p.key != "" {
+		for i := range *lines {
+			if (*lines)[i].Key == p.key {
+				(*lines)[i].Key = ""
+			}
+		}
+	}
+	*lines = append(*lines, Line{Raw: raw, Key: p.key, dirty: p.dirty})
+}
+
+// 読み込んだ行に読み込み時のフィールドの出力内容を記録する
+func (p *parser) record() {
+	record(p.cue.Album.Lines, p.cue.Album.Field.entries())
+	for i := range p.cue.Album.Command.Files {
+		file := &p.cue.Album.Command.Files[i]
+		record(file.Lines, file.entries())
+		for j := range file.Tracks {
+			record(file.Tracks[j].Lines, file.Tracks[j].entries())
+		}
+	}
+}
+
+// 読み込み中のトラックにINDEX 01があることを確認する
+func (p *parser) finishTrack() error {
+	if p.currentTrack == nil {
+		return nil
+	}
+	if _, ok := p.currentTrack.Command.SubCommand.Index(1); !ok {
+		err := &ParseError{
+			File:    p.path,
+			Line:    p.trackLine,
+			Column:  p.trackColumn,
+			Fatal:   true,
+			Message: fmt.Sprintf("TRACK %02dにINDEX 01がありません", p.currentTrack.Command.Track),
+		}
+		if !p.lenient {
+			return err
+		}
+		err.Warning = true
+		p.warnings = append(p.warnings, err)
+	}
+	return nil
+}
+
+// 引数の数を確認する
+func (p *parser) arguments(tokens []token, count int) error {
+	if len(tokens)-1 < count {
+		return p.error(tokens[0].Column+len([]rune(tokens[0].Value)), "%sの引数が不足しています", tokens[0].Value)
+	}
+	if count < len(tokens)-1 {
+		return p.warn(tokens[count+1].Column, "%sの引数が多すぎます", tokens[0].Value)
+	}
+	return nil
+}
+
+// 文字列の値の引数を求める
+// NOTE: 「TITLE My Album」のような「"」で囲まれていない空白を含む値は連結する
+// NOTE: 古いCUEシートに多いため厳格モードでも誤りとせず、寛容モードでは警告として記録して出力時に「"」で囲む
+func (p *parser) text(tokens []token) (string, error) {
+	if len(tokens) < 2 {
+		return "", p.arguments(tokens, 1)
+	}
+	if 2 < len(tokens) && p.lenient {
+		p.notice(tokens[2].Column, "%sの値が\"で囲まれていないため連結しました", tokens[0].Value)
+		p.dirty = true
+	}
+	return joinTokens(tokens[1:]), nil
+}
+
+func (p *parser) parseLine(tokens []token) error {
+	command := strings.ToUpper(tokens[0].Value)
+	// NOTE: FILEはトラックフィールド行以降にも存在するアルバムフィールドなので例外的に処理する
+	if command == "FILE" {
+		return p.parseFile(tokens)
+	}
+	if p.albumField {
+		return p.parseAlbumField(command, tokens)
+	}
+	return p.parseTrackField(command, tokens)
+}
+
+func (p *parser) parseFile(tokens []token) error {
+	if len(tokens) < 3 {
+		return p.arguments(tokens, 2)
+	}
+	if err := p.finishTrack(); err != nil {
+		return err
+	}
+	// NOTE: ファイル名に空白がある場合に対応するため、最後の要素を種類とし、それより前の要素を連結してファイル名とする
+	typeToken := tokens[len(tokens)-1]
+	fileName, err := p.text(tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	fileType := strings.ToUpper(typeToken.Value)
+	if !isFileType(fileType) {
+		return p.error(typeToken.Column, "FILEの種類\"%s\"に未対応です", typeToken.Value)
+	}
+	file := File{
+		Name: fileName,
+		Type: fileType,
+	}
+	// NOTE: FLAC・MP3は音声データを読み込まずにCUEシートのみを扱う
+	if canReadAudio(fileType) {
+		audioPath := filepath.Join(filepath.Dir(p.path), fileName)
+		// NOTE: 寛容モードでは音声データを読み込めないファイルとして扱う
+		waveInfo, audio, err := readAudio(audioPath, fileType)
+		if err == nil {
+			err = validateFormat(waveInfo.Format)
+		}
+		if err != nil {
+			if err := p.warn(tokens[1].Column, "%s: %v", fileName, err); err != nil {
+				return err
+			}
+		} else {
+			file.Wave = waveInfo
+			file.Audio = audio
+		}
+	}
+	p.cue.Album.Command.Files = append(p.cue.Album.Command.Files, file)
+	p.currentFile = &p.cue.Album.Command.Files[len(p.cue.Album.Command.Files)-1]
+	p.currentTrack = nil
+	p.lastPosition = 0
+	p.albumField = false
+	p.key = "FILE"
+	return nil
+}
+
+func (p *parser) parseAlbumField(command string, tokens []token) error {
+	field := &p.cue.Album.Field
+	switch command {
+	case "REM":
+		// NOTE: 値の無いREMはコメントとしてそのまま保持する
+		if len(tokens) < 3 {
+			return nil
+		}
+		key := strings.ToUpper(tokens[1].Value)
+		value := joinTokens(tokens[2:])
+		if key == "READ_ERROR" {
+			field.Rem.ReadErrors = append(field.Rem.ReadErrors, value)
+			p.key = fmt.Sprintf("REM READ_ERROR#%d", len(field.Rem.ReadErrors)-1)
+			return nil
+		}
+		target := field.remField(key)
+		if target == nil {
+			field.Rem.Unknown = append(field.Rem.Unknown, Rem{Key: tokens[1].Value, Value: value})
+			p.key = fmt.Sprintf("REM#%d", len(field.Rem.Unknown)-1)
+			return nil
+		}
+		*target = value
+		p.key = "REM " + key
+	case "CATALOG":
+		if err := p.arguments(tokens, 1); err != nil {
+			return err
+		}
+		// NOTE: 寛容モードでは「CATALOG 4 988064 123456」のような区切られた値を連結する
+		field.Catalog = joinTokens(tokens[1:])
+		p.key = command
+	case "CDTEXTFILE":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		field.CdTextFile = value
+		p.key = command
+	case "TITLE":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		field.Title = value
+		p.key = command
+	case "PERFORMER":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		field.Performer = value
+		p.key = command
+	case "SONGWRITER":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		field.Songwriter = value
+		p.key = command
+	default:
+		return p.warn(tokens[0].Column, "アルバムフィールドの\"%s\"に未対応です", tokens[0].Value)
+	}
+	return nil
+}
+
+func (a *AlbumField) remField(key string) *string {
+	switch key {
+	case "GENRE":
+		return &a.Rem.Genre
+	case "DATE":
+		return &a.Rem.Date
+	case "PUBLISHER":
+		return &a.Rem.Publisher
+	case "LABEL":
+		return &a.Rem.Label
+	case "PRODUCER":
+		return &a.Rem.Producer
+	case "PRODUCTION":
+		return &a.Rem.Production
+	case "WORK":
+		return &a.Rem.Work
+	case "BGM_WORK":
+		return &a.Rem.BGMWork
+	case "BGM_DIRECTOR":
+		return &a.Rem.BGMDirector
+	case "COMPOSER":
+		return &a.Rem.Composer
+	case "DISCNUMBER":
+		return &a.Rem.DiscNumber
+	case "TOTALDISCS":
+		return &a.Rem.TotalDiscs
+	case "DISCSUBTITLE":
+		return &a.Rem.DiscSubtitle
+	case "DISCID":
+		return &a.Rem.DiscId
+	case "JAN":
+		return &a.Rem.Jan
+	case "COMMENT":
+		return &a.Rem.Comment
+	}
+	return nil
+}
+
+func (p *parser) parseTrackField(command string, tokens []token) error {
+	if command == "TRACK" {
+		if err := p.arguments(tokens, 2); err != nil {
+			return err
+		}
+		dataType := strings.ToUpper(tokens[2].Value)
+		if !isTrackType(dataType) {
+			return p.error(tokens[2].Column, "TRACKの種類\"%s\"に未対応です", tokens[2].Value)
+		}
+		number, err := strconv.Atoi(tokens[1].Value)
+		if err != nil || number < 1 || 99 < number {
+			return p.error(tokens[1].Column, "トラック番号\"%s\"が不正です(01から99)", tokens[1].Value)
+		}
+		if err := p.finishTrack(); err != nil {
+			return err
+		}
+		p.currentFile.Tracks = append(
+			p.currentFile.Tracks,
+			Track{
+				Command: TrackCommand{
+					Track:    number,
+					DataType: dataType,
+				},
+			},
+		)
+		p.currentTrack = &p.currentFile.Tracks[len(p.currentFile.Tracks)-1]
+		p.trackLine = p.line
+		p.trackColumn = tokens[0].Column
+		p.key = command
+		return nil
+	}
+	if p.currentTrack == nil {
+		return p.warn(tokens[0].Column, "TRACKより前の\"%s\"に未対応です", tokens[0].Value)
+	}
+	track := p.currentTrack
+	switch command {
+	case "ISRC":
+		if err := p.arguments(tokens, 1); err != nil {
+			return err
+		}
+		track.Command.SubCommand.Isrc = tokens[1].Value
+		p.key = command
+	case "TITLE":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		track.Field.Title = value
+		p.key = command
+	case "PERFORMER":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		track.Field.Performer = value
+		p.key = command
+	case "SONGWRITER":
+		value, err := p.text(tokens)
+		if err != nil {
+			return err
+		}
+		track.Field.Songwriter = value
+		p.key = command
+	case "REM":
+		// NOTE: 値の無いREMはコメントとしてそのまま保持する
+		if len(tokens) < 3 {
+			return nil
+		}
+		key := strings.ToUpper(tokens[1].Value)
+		value := joinTokens(tokens[2:])
+		// NOTE: 既知の担当の種類のREMは担当者とし、同じ担当の複数の行を記録順に保持する
+		if _, ok := LookupRole(key); !ok {
+			track.Field.Rem.Unknown = append(track.Field.Rem.Unknown, Rem{Key: tokens[1].Value, Value: value})
+			p.key = fmt.Sprintf("REM#%d", len(track.Field.Rem.Unknown)-1)
+			return nil
+		}
+		track.Field.Credits = append(track.Field.Credits, Credit{Role: key, Name: value})
+		p.key = fmt.Sprintf("CREDIT#%d", len(track.Field.Credits)-1)
+	case "FLAGS":
+		for _, flag := range tokens[1:] {
+			switch strings.ToUpper(flag.Value) {
+			case "DCP":
+				track.Field.Flags.DigitalCopyPermitted = true
+			case "4CH":
+				track.Field.Flags.FourChannelAudio = true
+			case "PRE":
+				track.Field.Flags.PreEmphasisEnabled = true
+			case "SCMS":
+				track.Field.Flags.SerialCopyManagementSystem = true
+			default:
+				if err := p.warn(flag.Column, "FLAGSの\"%s\"に未対応です", flag.Value); err != nil {
+					return err
+				}
+			}
+		}
+		p.key = command
+	case "PREGAP":
+		if err := p.arguments(tokens, 1); err != nil {
+			return err
+		}
+		if len(track.Command.SubCommand.Indexes) != 0 {
+			return p.error(tokens[0].Column, "PREGAPはINDEXより前に指定してください")
+		}
+		length, err := p.timecode(tokens[1])
+		if err != nil {
+			return err
+		}
+		track.Command.SubCommand.Pregap = &length
+		p.key = command
+	case "POSTGAP":
+		if err := p.arguments(tokens, 1); err != nil {
+			return err
+		}
+		if len(track.Command.SubCommand.Indexes) == 0 {
+			return p.error(tokens[0].Column, "POSTGAPはINDEXより後に指定してください")
+		}
+		length, err := p.timecode(tokens[1])
+		if err != nil {
+			return err
+		}
+		track.Command.SubCommand.Postgap = &length
+		p.key = command
+	case "INDEX":
+		if err := p.arguments(tokens, 2); err != nil {
+			return err
+		}
+		if track.Command.SubCommand.Postgap != nil {
+			return p.error(tokens[0].Column, "INDEXはPOSTGAPより前に指定してください")
+		}
+		number, err := strconv.Atoi(tokens[1].Value)
+		if err != nil || number < 0 || 99 < number {
+			return p.error(tokens[1].Column, "インデックス番号\"%s\"が不正です(00から99)", tokens[1].Value)
+		}
+		// NOTE: 最初のインデックスは00か01で、以降は連番とする
+		indexes := track.Command.SubCommand.Indexes
+		if slices.ContainsFunc(indexes, func(index Index) bool {
+			return index.Number == number
+		}) {
+			return p.error(tokens[1].Column, "インデックス番号\"%s\"が重複しています", tokens[1].Value)
+		}
+		if len(indexes) == 0 && 1 < number {
+			if err := p.warn(tokens[1].Column, "最初のインデックス番号は00か01としてください"); err != nil {
+				return err
+			}
+		}
+		if len(indexes) != 0 && number != indexes[len(indexes)-1].Number+1 {
+			if err := p.warn(tokens[1].Column, "インデックス番号\"%s\"が連番ではありません", tokens[1].Value); err != nil {
+				return err
+			}
+		}
+		position, err := p.timecode(tokens[2])
+		if err != nil {
+			return err
+		}
+		if position < p.lastPosition {
+			if err := p.warn(tokens[2].Column, "INDEXの位置%sが前のINDEXの位置%sより前です", position, p.lastPosition); err != nil {
+				return err
+			}
+		}
+		p.lastPosition = max(p.lastPosition, position)
+		track.Command.SubCommand.Indexes = append(
+			indexes,
+			Index{
+				Number:   number,
+				Position: position,
+			},
+		)
+		p.key = fmt.Sprintf("INDEX %02d", number)
+	default:
+		return p.warn(tokens[0].Column, "トラックフィールドの\"%s\"に未対応です", tokens[0].Value)
+	}
+	return nil
+}
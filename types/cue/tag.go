@@ -0,0 +1,56 @@
+package cue
+
+import (
+	"strconv"
+)
+
+// Vorbisコメントのタグ
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// トラックに付けるタグ
+// NOTE: 空の値は出力しない
+func (c Cue) Tags(track Track) []Tag {
+	trackTotal := 0
+	for _, file := range c.Album.Command.Files {
+		trackTotal += len(file.Tracks)
+	}
+	performer := track.Field.Performer
+	if performer == "" {
+		performer = c.Album.Field.Performer
+	}
+	tags := []Tag{
+		{Name: "TITLE", Value: track.Field.Title},
+		{Name: "ARTIST", Value: performer},
+		{Name: "ALBUM", Value: c.Album.Field.Title},
+		{Name: "ALBUMARTIST", Value: c.Album.Field.Performer},
+		{Name: "TRACKNUMBER", Value: strconv.Itoa(track.Command.Track)},
+		{Name: "TRACKTOTAL", Value: strconv.Itoa(trackTotal)},
+		{Name: "DISCNUMBER", Value: c.Album.Field.Rem.DiscNumber},
+		{Name: "DISCTOTAL", Value: c.Album.Field.Rem.TotalDiscs},
+		{Name: "DISCSUBTITLE", Value: c.Album.Field.Rem.DiscSubtitle},
+		{Name: "DATE", Value: c.Album.Field.Rem.Date},
+		{Name: "GENRE", Value: c.Album.Field.Rem.Genre},
+		{Name: "LABEL", Value: c.Album.Field.Rem.Label},
+		{Name: "CATALOGNUMBER", Value: c.Album.Field.Catalog},
+		{Name: "BARCODE", Value: c.Album.Field.Rem.Jan},
+		{Name: "ISRC", Value: track.Command.SubCommand.Isrc},
+		{Name: "COMMENT", Value: c.Album.Field.Rem.Comment},
+	}
+	// NOTE: トラックに作曲者が無い場合はアルバムの作曲者とする
+	if len(track.Field.CreditNames("COMPOSER")) == 0 {
+		tags = append(tags, Tag{Name: "COMPOSER", Value: c.Album.Field.Rem.Composer})
+	}
+	for _, credit := range track.Field.Credits {
+		tags = append(tags, credit.Tag())
+	}
+	result := []Tag{}
+	for _, tag := range tags {
+		if tag.Value != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
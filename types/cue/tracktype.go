@@ -0,0 +1,32 @@
+package cue
+
+// TRACKの種類
+const (
+	TrackTypeAudio = "AUDIO"
+	// CD+G(カラオケ)
+	TrackTypeCdg = "CDG"
+	// CD-ROM Mode1(ユーザーデータのみ)
+	TrackTypeMode1 = "MODE1/2048"
+	// CD-ROM Mode1(セクタ全体)
+	TrackTypeMode1Raw = "MODE1/2352"
+	// CD-ROM XA Mode2(ユーザーデータのみ)
+	TrackTypeMode2 = "MODE2/2336"
+	// CD-ROM XA Mode2(セクタ全体)
+	TrackTypeMode2Raw = "MODE2/2352"
+	TrackTypeCdi      = "CDI/2336"
+	TrackTypeCdiRaw   = "CDI/2352"
+)
+
+func isTrackType(trackType string) bool {
+	switch trackType {
+	case TrackTypeAudio, TrackTypeCdg, TrackTypeMode1, TrackTypeMode1Raw, TrackTypeMode2, TrackTypeMode2Raw, TrackTypeCdi, TrackTypeCdiRaw:
+		return true
+	}
+	return false
+}
+
+// 音声のトラック
+// NOTE: 分割・出力など音声データを扱う処理は音声以外のトラックを対象外とする
+func (t Track) IsAudio() bool {
+	return t.Command.DataType == "" || t.Command.DataType == TrackTypeAudio
+}
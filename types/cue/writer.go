@@ -0,0 +1,136 @@
+package cue
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ryo-kagawa/Music/utils"
+)
+
+// CUEシートの文字コード
+// NOTE: 読み込み時の文字コードで出力できるよう、utils.DetectEncodingで判定できる文字コードに対応する
+const (
+	EncodingUTF8 = utils.EncodingUTF8
+	// 先頭にBOMを付けたUTF-8
+	EncodingUTF8BOM = "utf-8-bom"
+	// NOTE: Windowsの拡張文字を含むCP932として出力する
+	EncodingShiftJIS = utils.EncodingShiftJIS
+	EncodingEUCJP    = utils.EncodingEUCJP
+	// NOTE: UTF-16は常にBOMを付けて出力する
+	EncodingUTF16LE = utils.EncodingUTF16LE
+	EncodingUTF16BE = utils.EncodingUTF16BE
+	EncodingLatin1  = utils.EncodingLatin1
+)
+
+// 改行コード
+const (
+	LineEndingCRLF = "\r\n"
+	LineEndingLF   = "\n"
+)
+
+// CUEシートの出力の書式
+type WriteOptions struct {
+	// 文字コード(未指定の場合は読み込み時の文字コードとBOMの有無)
+	Encoding string
+	// 改行コード(未指定の場合は読み込み時の改行コード)
+	LineEnding string
+}
+
+// 文字コードの指定が正しいかを確認する
+func IsEncoding(name string) bool {
+	return name == EncodingUTF8BOM || utils.IsEncoding(name)
+}
+
+// CUEシートを1行ずつ書き込む
+// NOTE: 指定した文字コードで表現できない文字を含む場合は、その行を書き込まずにエラーとする
+func (c Cue) Write(writer io.Writer, options WriteOptions) error {
+	encodingName := options.Encoding
+	bom := false
+	if encodingName == "" {
+		encodingName = c.Layout.Encoding
+		bom = c.Layout.BOM
+	}
+	switch encodingName {
+	case "":
+		encodingName = EncodingUTF8
+	case EncodingUTF8BOM:
+		encodingName = EncodingUTF8
+		bom = true
+	case EncodingUTF16LE, EncodingUTF16BE:
+		// NOTE: BOMが無いUTF-16は読み込み時に判定できないため常に付与する
+		bom = true
+	}
+	if !IsEncoding(encodingName) {
+		return fmt.Errorf("文字コード%sには対応していません", encodingName)
+	}
+	lineEnding := options.LineEnding
+	if lineEnding == "" {
+		lineEnding = c.Layout.LineEnding
+	}
+	if lineEnding == "" {
+		lineEnding = LineEndingLF
+	}
+	encode := func(text string) (string, error) {
+		if encodingName == EncodingUTF8 {
+			return text, nil
+		}
+		return utils.EncodeText(text, encodingName)
+	}
+	bufferedWriter := bufio.NewWriter(writer)
+	write := func(text string) error {
+		encoded, err := encode(text)
+		if err != nil {
+			return err
+		}
+		_, err = bufferedWriter.WriteString(encoded)
+		return err
+	}
+	if bom {
+		if err := write("\uFEFF"); err != nil {
+			return err
+		}
+	}
+	number := 0
+	for line := range c.lines() {
+		if number != 0 {
+			if err := write(lineEnding); err != nil {
+				return err
+			}
+		}
+		number++
+		encoded, err := encode(line)
+		if err != nil {
+			return fmt.Errorf("%d行目: %sで表現できない文字が含まれています: %s", number, encodingName, line)
+		}
+		if _, err := bufferedWriter.WriteString(encoded); err != nil {
+			return err
+		}
+	}
+	if !c.Layout.NoTrailingNewline {
+		if err := write(lineEnding); err != nil {
+			return err
+		}
+	}
+	return bufferedWriter.Flush()
+}
+
+// CUEシートをファイルに出力する
+// NOTE: 書き込みに失敗した場合に既存のファイルを壊さないよう一時ファイルに書き込んでから置き換える
+func (c Cue) WriteCuefile(outputPath string, options WriteOptions) error {
+	tempPath := outputPath + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempPath)
+	defer file.Close()
+	if err := c.Write(file, options); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, outputPath)
+}
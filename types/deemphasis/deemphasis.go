@@ -0,0 +1,91 @@
+package deemphasis
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// 44.1kHz
+const samplingRate = 44100
+
+// 双一次変換に用いる時定数
+// NOTE: 50μs/15μsのまま変換すると高域で1dB以上ずれるため、20kHzまでの誤差が0.1dB未満となるよう調整した値を用いる
+const (
+	timeConstant1 = 49.5e-6
+	timeConstant2 = 16.69e-6
+)
+
+// 2CH
+const channels = 2
+
+// 出力するビット深度
+const OutputBitDepth = 24
+
+// 50μs/15μsのシェルビング特性を打ち消す44.1kHz用の1次IIRフィルタ
+// NOTE: H(s)=(1+sτ2)/(1+sτ1)を双一次変換したもの
+type Filter struct {
+	b0 float64
+	b1 float64
+	a1 float64
+	// 直前の入力と出力(チャンネル毎)
+	x1 [channels]float64
+	y1 [channels]float64
+}
+
+func New() *Filter {
+	k1 := 2 * samplingRate * timeConstant1
+	k2 := 2 * samplingRate * timeConstant2
+	return &Filter{
+		b0: (1 + k2) / (1 + k1),
+		b1: (1 - k2) / (1 + k1),
+		a1: (1 - k1) / (1 + k1),
+	}
+}
+
+// 16bitステレオの音声データにフィルタを適用し、24bitの音声データとして返す
+// NOTE: フィルタの状態を保持するため、続きのデータを順に渡すことができる
+// NOTE: 1サンプルに満たない末尾のデータは無視する
+func (f *Filter) Apply(data []byte) []byte {
+	sampleCount := len(data) / 2
+	result := make([]byte, 0, sampleCount*3)
+	for i := range sampleCount {
+		channel := i % channels
+		x := float64(int16(binary.LittleEndian.Uint16(data[i*2:])))
+		y := f.b0*x + f.b1*f.x1[channel] - f.a1*f.y1[channel]
+		f.x1[channel] = x
+		f.y1[channel] = y
+		// NOTE: 16bitから24bitへ拡張した値に丸める
+		value := int32(max(min(math.Round(y*256), math.MaxInt32>>8), math.MinInt32>>8))
+		result = append(result, byte(value), byte(value>>8), byte(value>>16))
+	}
+	return result
+}
+
+// 書き込まれた16bitステレオの音声データにフィルタを適用し、24bitの音声データとしてwriterへ書き込む
+type Writer struct {
+	filter    *Filter
+	writer    io.Writer
+	remainder []byte
+}
+
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{
+		filter: New(),
+		writer: writer,
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(w.remainder) != 0 {
+		p = append(w.remainder, p...)
+		w.remainder = nil
+	}
+	length := len(p) / 2 * 2
+	if _, err := w.writer.Write(w.filter.Apply(p[:length])); err != nil {
+		return 0, err
+	}
+	w.remainder = append(w.remainder, p[length:]...)
+	return n, nil
+}
@@ -0,0 +1,64 @@
+package riplog
+
+import (
+	"fmt"
+	"hash"
+	"hash/crc32"
+
+	"github.com/ryo-kagawa/Music/types/accuraterip"
+)
+
+// 1トラック分の16bitステレオのデータからピークレベルとチェックサムを計算する
+type Analyzer struct {
+	crc         hash.Hash32
+	accurateRip *accuraterip.Hasher
+	peak        int
+	remainder   []byte
+}
+
+// sampleCountはトラックのサンプル数、first/lastは先頭・最終トラックであるか
+func NewAnalyzer(sampleCount int, first bool, last bool) *Analyzer {
+	return &Analyzer{
+		crc:         crc32.NewIEEE(),
+		accurateRip: accuraterip.NewHasher(sampleCount, first, last),
+		remainder:   make([]byte, 0, 2),
+	}
+}
+
+func (a *Analyzer) Write(p []byte) (int, error) {
+	n := len(p)
+	a.crc.Write(p)
+	a.accurateRip.Write(p)
+	if len(a.remainder) != 0 && len(p) != 0 {
+		a.addSample(a.remainder[0], p[0])
+		a.remainder = a.remainder[:0]
+		p = p[1:]
+	}
+	for ; 2 <= len(p); p = p[2:] {
+		a.addSample(p[0], p[1])
+	}
+	a.remainder = append(a.remainder, p...)
+	return n, nil
+}
+
+func (a *Analyzer) addSample(low byte, high byte) {
+	sample := int(int16(uint16(low) | uint16(high)<<8))
+	a.peak = max(a.peak, sample, -sample)
+}
+
+// ピークレベル(%)
+func (a *Analyzer) Peak() float64 {
+	return float64(a.peak) * 100 / 32768
+}
+
+func (a *Analyzer) CRC() string {
+	return FormatCRC(a.crc.Sum32())
+}
+
+func (a *Analyzer) AccurateRip() accuraterip.Checksum {
+	return a.accurateRip.Sum()
+}
+
+func FormatCRC(crc uint32) string {
+	return fmt.Sprintf("%08X", crc)
+}
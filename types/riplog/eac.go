@@ -0,0 +1,125 @@
+package riplog
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/utils"
+)
+
+var eacTrackPattern = regexp.MustCompile(`^Track\s+(\d+)$`)
+var eacAccurateRipPattern = regexp.MustCompile(`^Accurately ripped \(confidence (\d+)(?:/(\d+))?\)\s*\[(?:(v[12]) )?([0-9A-Fa-f]{8})\](?:\s*\(AR (v[12])\))?`)
+
+// EACのログ(英語)を読み込む
+// NOTE: cd-ripのログも同じ書式のため、ここで読み込む
+func parseEAC(text string) (Log, error) {
+	log := Log{}
+	var track *Track
+	for line := range utils.SplitNewLineWithoutEmpty(text) {
+		line = strings.TrimSpace(line)
+		if entry, ok := parseTOCLine(line); ok {
+			log.TOC = append(log.TOC, entry)
+			continue
+		}
+		if match := eacTrackPattern.FindStringSubmatch(line); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			log.Tracks = append(log.Tracks, Track{Number: number})
+			track = &log.Tracks[len(log.Tracks)-1]
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "Exact Audio Copy"):
+			log.Application = line
+			continue
+		case strings.HasPrefix(line, "cd-rip extraction logfile"):
+			log.Application = "cd-rip"
+			continue
+		case line == "Range status and errors":
+			// NOTE: イメージとして読み込んだ場合は全体を1トラックとして扱う
+			log.Tracks = append(log.Tracks, Track{Number: 0})
+			track = &log.Tracks[len(log.Tracks)-1]
+			continue
+		case strings.HasPrefix(line, "==== Log checksum"),
+			strings.HasPrefix(line, "==== Log signature"):
+			continue
+		}
+		if track == nil {
+			key, value, ok := cutField(line)
+			if !ok {
+				continue
+			}
+			switch key {
+			case "Used drive":
+				// NOTE: 「Adapter: 1  ID: 0」が続く場合は除外する
+				drive, _, _ := strings.Cut(value, "Adapter:")
+				log.Drive = strings.TrimSpace(drive)
+			case "Read mode":
+				log.ReadMode = value
+			case "Read offset correction", "Read offset":
+				offset, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+				if err != nil {
+					return Log{}, err
+				}
+				log.ReadOffset = offset
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "Filename "):
+			track.Filename = strings.TrimSpace(strings.TrimPrefix(line, "Filename "))
+		case strings.HasPrefix(line, "Peak level "):
+			peak, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "Peak level "), "%")), 64)
+			if err != nil {
+				return Log{}, err
+			}
+			track.Peak = peak
+		case strings.HasPrefix(line, "Test CRC "):
+			track.TestCRC = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(line, "Test CRC ")))
+		case strings.HasPrefix(line, "Copy CRC "):
+			track.CopyCRC = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(line, "Copy CRC ")))
+		case strings.HasPrefix(line, "Suspicious position "):
+			position, ok := parsePosition(strings.TrimSpace(strings.TrimPrefix(line, "Suspicious position ")))
+			if !ok {
+				continue
+			}
+			// NOTE: EACはトラックの先頭からの位置で出力する
+			if log.Application != "cd-rip" {
+				if entry, ok := log.tocEntry(track.Number); ok {
+					position += entry.StartLBA
+				}
+			}
+			track.Suspicious = append(track.Suspicious, position)
+		case strings.HasPrefix(line, "Unreadable position "):
+			position, ok := parsePosition(strings.TrimSpace(strings.TrimPrefix(line, "Unreadable position ")))
+			if !ok {
+				continue
+			}
+			track.Unreadable = append(track.Unreadable, position)
+		default:
+			if match := eacAccurateRipPattern.FindStringSubmatch(line); match != nil {
+				confidence, _ := strconv.Atoi(match[1])
+				total, _ := strconv.Atoi(match[2])
+				version := match[3] + match[5]
+				accurateRip := &AccurateRip{
+					Version:    version,
+					Confidence: confidence,
+					Total:      max(total, confidence),
+				}
+				switch version {
+				case "v2":
+					accurateRip.V2 = strings.ToUpper(match[4])
+				default:
+					accurateRip.Version = "v1"
+					accurateRip.V1 = strings.ToUpper(match[4])
+				}
+				track.AccurateRip = accurateRip
+			}
+		}
+	}
+	if len(log.Tracks) == 0 {
+		return Log{}, errors.New("ログにトラックの情報がありません")
+	}
+	return log, nil
+}
@@ -0,0 +1,94 @@
+package riplog
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/utils"
+)
+
+// EAC/XLD/cd-ripのテキスト形式のログを読み込む
+func Load(logFilepath string) (Log, error) {
+	text, err := utils.ReadTextFileToUTF8(logFilepath)
+	if err != nil {
+		return Log{}, err
+	}
+	return Parse(text)
+}
+
+func Parse(text string) (Log, error) {
+	for line := range utils.SplitNewLineWithoutEmpty(text) {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "X Lossless Decoder"):
+			return parseXLD(text)
+		case strings.HasPrefix(line, "Exact Audio Copy"),
+			strings.HasPrefix(line, "EAC extraction logfile"),
+			strings.HasPrefix(line, "cd-rip extraction logfile"):
+			return parseEAC(text)
+		default:
+			return Log{}, errors.New("ログの形式が特定できませんでした")
+		}
+	}
+	return Log{}, errors.New("ログが空です")
+}
+
+// 「キー : 値」形式の行を分割する
+func cutField(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+var tocLinePattern = regexp.MustCompile(`^(\d+)\s*\|[^|]*\|[^|]*\|\s*(\d+)\s*\|\s*(\d+)\s*$`)
+
+// TOCの表の行を読み込む
+// NOTE: 開始・終了セクタの列のみを使用するため、時間の表記(EAC: m:ss.ff, XLD: mm:ss:ff)には依存しない
+func parseTOCLine(line string) (TOCEntry, bool) {
+	match := tocLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return TOCEntry{}, false
+	}
+	track, _ := strconv.Atoi(match[1])
+	startLBA, _ := strconv.Atoi(match[2])
+	endLBA, _ := strconv.Atoi(match[3])
+	return TOCEntry{
+		Track:    track,
+		StartLBA: startLBA,
+		EndLBA:   endLBA + 1,
+	}, true
+}
+
+// 「m:ss:ff」または「m:ss.ff」をセクタ数に変換する
+func parsePosition(value string) (int, bool) {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ':' || r == '.'
+	})
+	if len(fields) != 3 {
+		return 0, false
+	}
+	result := 0
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return 0, false
+		}
+		result = result*[]int{1, 60, 75}[i] + number
+	}
+	return result, true
+}
+
+func (l Log) tocEntry(track int) (TOCEntry, bool) {
+	for _, entry := range l.TOC {
+		if entry.Track == track {
+			return entry, true
+		}
+	}
+	return TOCEntry{}, false
+}
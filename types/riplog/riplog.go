@@ -0,0 +1,343 @@
+package riplog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NOTE: 鍵を知らない者が改ざん後に作り直せないよう、内容のHMAC-SHA256を署名とする
+const signaturePrefix = "==== Log signature (HMAC-SHA256) "
+
+// NOTE: 鍵が無い場合は内容のSHA-256をチェックサムとする(誰でも作り直せるため、破損・誤った編集の検出のみを目的とする)
+// NOTE: EACの「==== Log checksum」とは形式が異なるため「(SHA-256)」で区別する
+const checksumPrefix = "==== Log checksum (SHA-256) "
+const signatureSuffix = " ===="
+
+// 検証した方式
+const (
+	IntegritySignature = "signature"
+	IntegrityChecksum  = "checksum"
+)
+
+var (
+	// 署名・チェックサムが無い
+	ErrorNoSignature = errors.New("ログに署名がありません")
+	// 署名を検証する鍵が設定されていない
+	ErrorNoKey = errors.New("ログの署名を検証する鍵が設定されていません")
+	// 署名・チェックサムが内容と一致しない
+	ErrorIntegrityMismatch = errors.New("ログの内容が署名・チェックサムと一致しません")
+)
+
+type TOCEntry struct {
+	Track int `json:"track"`
+	// トラック01の開始位置を0とした位置
+	StartLBA int `json:"startLBA"`
+	EndLBA   int `json:"endLBA"`
+}
+
+type AccurateRip struct {
+	V1 string `json:"v1"`
+	V2 string `json:"v2"`
+	// 一致したチェックサムの種類(v1/v2)、一致しない場合は空文字
+	Version    string `json:"version,omitempty"`
+	Confidence int    `json:"confidence"`
+	// データベースに登録されている最大の信頼度
+	Total int `json:"total"`
+}
+
+type Track struct {
+	Number   int    `json:"number"`
+	Filename string `json:"filename,omitempty"`
+	// ピークレベル(%)
+	Peak    float64 `json:"peak"`
+	TestCRC string  `json:"testCRC,omitempty"`
+	CopyCRC string  `json:"copyCRC"`
+	// データベースを参照できなかった場合はnil
+	AccurateRip *AccurateRip `json:"accurateRip,omitempty"`
+	// 読み込みに再試行を要したセクタ(トラック01の開始位置を0とした位置)
+	Suspicious []int `json:"suspicious,omitempty"`
+	// 読み込めずに無音に置き換えたセクタ(トラック01の開始位置を0とした位置)
+	Unreadable []int `json:"unreadable,omitempty"`
+}
+
+const (
+	// 再試行の末に読み込めた
+	ErrorKindSuspicious = "suspicious"
+	// 読み込めずに無音に置き換えた
+	ErrorKindUnreadable = "unreadable"
+)
+
+// 読み込みに問題があったセクタの範囲
+type ErrorRange struct {
+	Kind     string `json:"kind"`
+	StartLBA int    `json:"startLBA"`
+	// 範囲の末尾の次の位置
+	EndLBA int `json:"endLBA"`
+}
+
+// 連続するセクタをまとめて範囲に変換する
+func NewErrorMap(kind string, lbas []int) []ErrorRange {
+	lbas = slices.Clone(lbas)
+	slices.Sort(lbas)
+	result := []ErrorRange{}
+	for _, lba := range slices.Compact(lbas) {
+		if len(result) != 0 && result[len(result)-1].EndLBA == lba {
+			result[len(result)-1].EndLBA++
+			continue
+		}
+		result = append(
+			result,
+			ErrorRange{
+				Kind:     kind,
+				StartLBA: lba,
+				EndLBA:   lba + 1,
+			},
+		)
+	}
+	return result
+}
+
+type Log struct {
+	Application string     `json:"application"`
+	Date        time.Time  `json:"date"`
+	Drive       string     `json:"drive"`
+	ReadOffset  int        `json:"readOffset"`
+	ReadMode    string     `json:"readMode"`
+	VerifyCount int        `json:"verifyCount"`
+	TOC         []TOCEntry `json:"toc"`
+	// AccurateRipデータベースの参照結果
+	AccurateRip string `json:"accurateRip"`
+	// 読み込みに問題があった範囲
+	ErrorMap []ErrorRange `json:"errorMap,omitempty"`
+	Tracks   []Track      `json:"tracks"`
+	// Signature・Checksumを空にした内容のHMAC-SHA256(鍵が無い場合は空)
+	Signature string `json:"signature,omitempty"`
+	// Signature・Checksumを空にした内容のSHA-256(鍵がある場合は空)
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// セクタ数を「分:秒.フレーム」に変換する
+func formatLength(sectors int) string {
+	return fmt.Sprintf("%d:%02d.%02d", sectors/75/60, sectors/75%60, sectors%75)
+}
+
+// セクタ位置を「分:秒:フレーム」に変換する
+func formatPosition(sectors int) string {
+	return fmt.Sprintf("%d:%02d:%02d", sectors/75/60, sectors/75%60, sectors%75)
+}
+
+func (l Log) body() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s extraction logfile from %s\n", l.Application, l.Date.Format("2006-01-02 15:04:05"))
+	builder.WriteString("\n")
+	fmt.Fprintf(&builder, "Used drive   : %s\n", l.Drive)
+	fmt.Fprintf(&builder, "Read offset  : %+d\n", l.ReadOffset)
+	fmt.Fprintf(&builder, "Read mode    : %s\n", l.ReadMode)
+	fmt.Fprintf(&builder, "Verify count : %d\n", l.VerifyCount)
+	builder.WriteString("\n")
+	builder.WriteString("TOC of the extracted CD\n")
+	builder.WriteString("\n")
+	builder.WriteString("     Track |   Start  |  Length  | Start sector | End sector\n")
+	builder.WriteString("    ---------------------------------------------------------\n")
+	for _, entry := range l.TOC {
+		fmt.Fprintf(
+			&builder,
+			"       %2d  | %8s | %8s |    %9d | %9d\n",
+			entry.Track,
+			formatLength(entry.StartLBA),
+			formatLength(entry.EndLBA-entry.StartLBA),
+			entry.StartLBA,
+			entry.EndLBA-1,
+		)
+	}
+	builder.WriteString("\n")
+	fmt.Fprintf(&builder, "AccurateRip : %s\n", l.AccurateRip)
+	if len(l.ErrorMap) != 0 {
+		builder.WriteString("\n")
+		builder.WriteString("Error map\n")
+		builder.WriteString("\n")
+		for _, errorRange := range l.ErrorMap {
+			fmt.Fprintf(
+				&builder,
+				"     %-10s  %s - %s  (%d sectors)\n",
+				errorRange.Kind,
+				formatPosition(errorRange.StartLBA),
+				formatPosition(errorRange.EndLBA-1),
+				errorRange.EndLBA-errorRange.StartLBA,
+			)
+		}
+	}
+	for _, track := range l.Tracks {
+		builder.WriteString("\n")
+		fmt.Fprintf(&builder, "Track %2d\n", track.Number)
+		builder.WriteString("\n")
+		if track.Filename != "" {
+			fmt.Fprintf(&builder, "     Filename %s\n", track.Filename)
+			builder.WriteString("\n")
+		}
+		fmt.Fprintf(&builder, "     Peak level %.1f %%\n", track.Peak)
+		if track.TestCRC != "" {
+			fmt.Fprintf(&builder, "     Test CRC %s\n", track.TestCRC)
+		}
+		fmt.Fprintf(&builder, "     Copy CRC %s\n", track.CopyCRC)
+		if track.AccurateRip != nil {
+			switch {
+			case track.AccurateRip.Version != "":
+				fmt.Fprintf(
+					&builder,
+					"     Accurately ripped (confidence %d/%d)  [%s %s]\n",
+					track.AccurateRip.Confidence,
+					track.AccurateRip.Total,
+					track.AccurateRip.Version,
+					map[string]string{"v1": track.AccurateRip.V1, "v2": track.AccurateRip.V2}[track.AccurateRip.Version],
+				)
+			case track.AccurateRip.Total != 0:
+				fmt.Fprintf(&builder, "     Cannot be verified as accurate (confidence %d)  [v1 %s, v2 %s]\n", track.AccurateRip.Total, track.AccurateRip.V1, track.AccurateRip.V2)
+			default:
+				fmt.Fprintf(&builder, "     Track not present in AccurateRip database  [v1 %s, v2 %s]\n", track.AccurateRip.V1, track.AccurateRip.V2)
+			}
+		}
+		for _, lba := range track.Suspicious {
+			fmt.Fprintf(&builder, "     Suspicious position %s\n", formatPosition(lba))
+		}
+		for _, lba := range track.Unreadable {
+			fmt.Fprintf(&builder, "     Unreadable position %s\n", formatPosition(lba))
+		}
+		// NOTE: 再試行・読み込めないセクタがある場合もCRCの不一致を隠さないよう併記する
+		status := func() string {
+			switch {
+			case len(track.Unreadable) != 0:
+				return "     Copy finished with errors"
+			case len(track.Suspicious) != 0,
+				track.TestCRC != "" && track.TestCRC != track.CopyCRC:
+				return "     Copy finished"
+			default:
+				return "     Copy OK"
+			}
+		}()
+		if track.TestCRC != "" && track.TestCRC != track.CopyCRC {
+			status += " (CRC mismatch)"
+		}
+		builder.WriteString(status + "\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+func sign(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(mac.Sum(nil)))
+}
+
+func checksum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// 署名・チェックサムが内容と一致するかを確認し、検証した方式を返す
+// NOTE: 鍵を設定している場合は、署名をチェックサムに置き換える改ざんを見逃さないよう、チェックサムが一致してもErrorNoSignatureを返す
+func verify(key []byte, value string, signature string, sum string) (string, error) {
+	switch {
+	case signature != "":
+		if len(key) == 0 {
+			return "", ErrorNoKey
+		}
+		if !hmac.Equal([]byte(sign(key, value)), []byte(strings.ToUpper(signature))) {
+			return "", ErrorIntegrityMismatch
+		}
+		return IntegritySignature, nil
+	case sum != "":
+		if checksum(value) != strings.ToUpper(sum) {
+			return "", ErrorIntegrityMismatch
+		}
+		if len(key) != 0 {
+			return IntegrityChecksum, ErrorNoSignature
+		}
+		return IntegrityChecksum, nil
+	default:
+		return "", ErrorNoSignature
+	}
+}
+
+// テキスト形式のログ
+// NOTE: 鍵を指定した場合は末尾に内容の署名を、指定しない場合はチェックサムを付与する
+func (l Log) Text(key []byte) string {
+	body := l.body()
+	if len(key) == 0 {
+		return body + checksumPrefix + checksum(body) + signatureSuffix + "\n"
+	}
+	return body + signaturePrefix + sign(key, body) + signatureSuffix + "\n"
+}
+
+// テキスト形式のログの署名・チェックサムを検証し、検証した方式を返す
+func VerifyText(text string, key []byte) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	signature, sum := "", ""
+	index := strings.LastIndex(text, signaturePrefix)
+	prefix := signaturePrefix
+	if index < 0 {
+		index = strings.LastIndex(text, checksumPrefix)
+		prefix = checksumPrefix
+	}
+	if index < 0 {
+		return verify(key, text, "", "")
+	}
+	value, rest, _ := strings.Cut(strings.TrimPrefix(text[index:], prefix), signatureSuffix)
+	if strings.TrimSpace(rest) != "" {
+		return "", fmt.Errorf("%w(署名・チェックサムの後に内容があります)", ErrorIntegrityMismatch)
+	}
+	if prefix == signaturePrefix {
+		signature = value
+	} else {
+		sum = value
+	}
+	return verify(key, text[:index], signature, sum)
+}
+
+func (l Log) jsonBody() (string, error) {
+	l.Signature = ""
+	l.Checksum = ""
+	value, err := json.Marshal(l)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// JSON形式のログ
+// NOTE: 鍵を指定した場合はSignatureを、指定しない場合はChecksumを設定する
+func (l Log) JSON(key []byte) ([]byte, error) {
+	body, err := l.jsonBody()
+	if err != nil {
+		return nil, err
+	}
+	l.Signature = ""
+	l.Checksum = ""
+	if len(key) == 0 {
+		l.Checksum = checksum(body)
+	} else {
+		l.Signature = sign(key, body)
+	}
+	return json.MarshalIndent(l, "", "  ")
+}
+
+// JSON形式のログの署名・チェックサムを検証し、検証した方式を返す
+func VerifyJSON(value []byte, key []byte) (string, error) {
+	l := Log{}
+	if err := json.Unmarshal(value, &l); err != nil {
+		return "", err
+	}
+	body, err := l.jsonBody()
+	if err != nil {
+		return "", err
+	}
+	return verify(key, body, l.Signature, l.Checksum)
+}
@@ -0,0 +1,93 @@
+package riplog
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ryo-kagawa/Music/utils"
+)
+
+var xldTrackPattern = regexp.MustCompile(`^Track\s+(\d+)$`)
+var xldAccurateRipPattern = regexp.MustCompile(`^->Accurately ripped \((v1\+v2|v1|v2), confidence ([0-9+]+)/(\d+)\)`)
+
+// XLDのログを読み込む
+func parseXLD(text string) (Log, error) {
+	log := Log{}
+	var track *Track
+	for line := range utils.SplitNewLineWithoutEmpty(text) {
+		line = strings.TrimSpace(line)
+		if entry, ok := parseTOCLine(line); ok {
+			log.TOC = append(log.TOC, entry)
+			continue
+		}
+		if match := xldTrackPattern.FindStringSubmatch(line); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			log.Tracks = append(log.Tracks, Track{Number: number})
+			track = &log.Tracks[len(log.Tracks)-1]
+			continue
+		}
+		if strings.HasPrefix(line, "X Lossless Decoder") {
+			log.Application = line
+			continue
+		}
+		if match := xldAccurateRipPattern.FindStringSubmatch(line); match != nil && track != nil {
+			confidence := 0
+			for value := range strings.SplitSeq(match[2], "+") {
+				number, _ := strconv.Atoi(value)
+				confidence += number
+			}
+			total, _ := strconv.Atoi(match[3])
+			if track.AccurateRip == nil {
+				track.AccurateRip = &AccurateRip{}
+			}
+			track.AccurateRip.Version = strings.TrimPrefix(match[1], "v1+")
+			track.AccurateRip.Confidence = confidence
+			track.AccurateRip.Total = total
+			continue
+		}
+		key, value, ok := cutField(line)
+		if !ok {
+			continue
+		}
+		if track == nil {
+			switch key {
+			case "Used drive":
+				log.Drive = value
+			case "Ripper mode":
+				log.ReadMode = value
+			case "Read offset correction":
+				offset, err := strconv.Atoi(value)
+				if err != nil {
+					return Log{}, err
+				}
+				log.ReadOffset = offset
+			}
+			continue
+		}
+		switch key {
+		case "Filename":
+			track.Filename = value
+		case "CRC32 hash (test run)":
+			track.TestCRC = strings.ToUpper(value)
+		case "CRC32 hash":
+			track.CopyCRC = strings.ToUpper(value)
+		case "AccurateRip v1 signature", "AccurateRip v2 signature":
+			if track.AccurateRip == nil {
+				track.AccurateRip = &AccurateRip{}
+			}
+			// NOTE: 「XXXXXXXX (YYYYYYYY)」のように補足が続く場合がある
+			signature, _, _ := strings.Cut(value, " ")
+			if key == "AccurateRip v1 signature" {
+				track.AccurateRip.V1 = strings.ToUpper(signature)
+			} else {
+				track.AccurateRip.V2 = strings.ToUpper(signature)
+			}
+		}
+	}
+	if len(log.Tracks) == 0 {
+		return Log{}, errors.New("ログにトラックの情報がありません")
+	}
+	return log, nil
+}
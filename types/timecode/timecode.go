@@ -0,0 +1,129 @@
+package timecode
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// 1秒あたりのフレーム(セクタ)数
+const FramesPerSecond = 75
+
+// 1フレームあたりのサンプル数(44.1kHz / 75)
+const SamplesPerFrame = 588
+
+// CDDA(16bitステレオ)の1サンプルあたりのバイト数
+const BytesPerSample = 4
+
+// 1フレームあたりのバイト数(2352)
+const BytesPerFrame = SamplesPerFrame * BytesPerSample
+
+// ディスク先頭のリードイン分のフレーム数(MSF 00:02:00がLBA 0となる)
+const LBAOffset = 2 * FramesPerSecond
+
+// フレーム(1/75秒)単位の位置・長さ
+type Timecode int
+
+var pattern = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})$`)
+
+// 「分:秒:フレーム」の形式を解釈する
+func Parse(value string) (Timecode, error) {
+	match := pattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("時間の形式が不正です(mm:ss:ff): %s", value)
+	}
+	minutes, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, fmt.Errorf("時間の形式が不正です(mm:ss:ff): %s", value)
+	}
+	seconds, _ := strconv.Atoi(match[2])
+	frames, _ := strconv.Atoi(match[3])
+	if 60 <= seconds {
+		return 0, fmt.Errorf("秒は00から59の範囲で指定してください: %s", value)
+	}
+	if FramesPerSecond <= frames {
+		return 0, fmt.Errorf("フレームは00から74の範囲で指定してください: %s", value)
+	}
+	return FromMSF(minutes, seconds, frames), nil
+}
+
+// 範囲外の秒・フレームを繰り上げながら「分:秒:フレーム」の形式を解釈する
+// NOTE: 00:01:80のような誤った時間を修正する場合に使用する
+func ParseLenient(value string) (Timecode, error) {
+	match := pattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("時間の形式が不正です(mm:ss:ff): %s", value)
+	}
+	minutes, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, fmt.Errorf("時間の形式が不正です(mm:ss:ff): %s", value)
+	}
+	seconds, _ := strconv.Atoi(match[2])
+	frames, _ := strconv.Atoi(match[3])
+	return FromMSF(minutes, seconds, frames), nil
+}
+
+func FromMSF(minutes int, seconds int, frames int) Timecode {
+	return Timecode((minutes*60+seconds)*FramesPerSecond + frames)
+}
+
+// NOTE: LBAはMSF 00:02:00を0とするため、リードイン分を加算する
+func FromLBA(lba int) Timecode {
+	return Timecode(lba + LBAOffset)
+}
+
+// NOTE: フレームの途中の位置は切り捨てる
+func FromSamples(samples int) Timecode {
+	return Timecode(samples / SamplesPerFrame)
+}
+
+// NOTE: フレームの途中の位置は切り捨てる
+func FromBytes(bytes int) Timecode {
+	return Timecode(bytes / BytesPerFrame)
+}
+
+func (t Timecode) MSF() (int, int, int) {
+	return int(t) / FramesPerSecond / 60, int(t) / FramesPerSecond % 60, int(t) % FramesPerSecond
+}
+
+func (t Timecode) Frames() int {
+	return int(t)
+}
+
+func (t Timecode) LBA() int {
+	return int(t) - LBAOffset
+}
+
+func (t Timecode) Samples() int {
+	return int(t) * SamplesPerFrame
+}
+
+// CDDA(16bitステレオ)でのバイト数
+func (t Timecode) Bytes() int {
+	return t.Samples() * BytesPerSample
+}
+
+// 任意のサンプリングレートでのサンプル数
+// NOTE: 1フレームが整数のサンプル数にならない場合は切り捨てる
+func (t Timecode) SampleOffset(samplingRate int) int {
+	return int(int64(t) * int64(samplingRate) / FramesPerSecond)
+}
+
+// 1サンプル(全チャンネル)あたりblockAlignバイトの音声データでのバイト数
+func (t Timecode) ByteOffset(samplingRate int, blockAlign int) int {
+	return t.SampleOffset(samplingRate) * blockAlign
+}
+
+func (t Timecode) Add(other Timecode) Timecode {
+	return t + other
+}
+
+func (t Timecode) Sub(other Timecode) Timecode {
+	return t - other
+}
+
+// 「分:秒:フレーム」の形式
+func (t Timecode) String() string {
+	minutes, seconds, frames := t.MSF()
+	return fmt.Sprintf("%02d:%02d:%02d", minutes, seconds, frames)
+}
@@ -0,0 +1,120 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+)
+
+// CodingHistoryを除くbextチャンクのサイズ
+const bextSize = 602
+
+// Broadcast Wave Format(EBU Tech 3285)のbextチャンク
+type BroadcastExtension struct {
+	Description         string
+	Originator          string
+	OriginatorReference string
+	// yyyy-mm-dd
+	OriginationDate string
+	// hh:mm:ss
+	OriginationTime string
+	// 深夜0時からのサンプル数
+	TimeReference uint64
+	Version       uint16
+	UMID          [64]byte
+	// Version 2以降のラウドネス(0.01単位)
+	LoudnessValue        int16
+	LoudnessRange        int16
+	MaxTruePeakLevel     int16
+	MaxMomentaryLoudness int16
+	MaxShortTermLoudness int16
+	CodingHistory        string
+}
+
+// 固定長の文字列フィールド(末尾はNULで埋める)
+func fixedString(value []byte) string {
+	value, _, _ = bytes.Cut(value, []byte{0x00})
+	return string(value)
+}
+
+func appendFixedString(value []byte, text string, size int) []byte {
+	field := make([]byte, size)
+	copy(field, text)
+	return append(value, field...)
+}
+
+// bextチャンクを持たない場合はfalseを返す
+func (w Wave) BroadcastExtension() (BroadcastExtension, bool, error) {
+	index := slices.IndexFunc(w.Chunks, func(chunk Chunk) bool {
+		return chunk.ID == "bext"
+	})
+	if index < 0 {
+		return BroadcastExtension{}, false, nil
+	}
+	value := w.Chunks[index].Data
+	if len(value) < bextSize {
+		return BroadcastExtension{}, false, &FormatError{Message: "bextチャンクが短すぎます"}
+	}
+	// 0-255: Description
+	// 256-287: Originator
+	// 288-319: OriginatorReference
+	// 320-329: OriginationDate
+	// 330-337: OriginationTime
+	// 338-345: TimeReference
+	// 346-347: Version
+	// 348-411: UMID
+	// 412-421: ラウドネス
+	// 422-601: Reserved
+	// 602-: CodingHistory
+	result := BroadcastExtension{
+		Description:          fixedString(value[0:256]),
+		Originator:           fixedString(value[256:288]),
+		OriginatorReference:  fixedString(value[288:320]),
+		OriginationDate:      fixedString(value[320:330]),
+		OriginationTime:      fixedString(value[330:338]),
+		TimeReference:        binary.LittleEndian.Uint64(value[338:346]),
+		Version:              binary.LittleEndian.Uint16(value[346:348]),
+		LoudnessValue:        int16(binary.LittleEndian.Uint16(value[412:414])),
+		LoudnessRange:        int16(binary.LittleEndian.Uint16(value[414:416])),
+		MaxTruePeakLevel:     int16(binary.LittleEndian.Uint16(value[416:418])),
+		MaxMomentaryLoudness: int16(binary.LittleEndian.Uint16(value[418:420])),
+		MaxShortTermLoudness: int16(binary.LittleEndian.Uint16(value[420:422])),
+		CodingHistory:        fixedString(value[bextSize:]),
+	}
+	copy(result.UMID[:], value[348:412])
+	return result, true, nil
+}
+
+// bextチャンクを設定する
+// NOTE: 既にある場合は同じ位置で置き換え、ない場合はdataチャンクの前に追加する
+func (w *Wave) SetBroadcastExtension(extension BroadcastExtension) {
+	value := appendFixedString(nil, extension.Description, 256)
+	value = appendFixedString(value, extension.Originator, 32)
+	value = appendFixedString(value, extension.OriginatorReference, 32)
+	value = appendFixedString(value, extension.OriginationDate, 10)
+	value = appendFixedString(value, extension.OriginationTime, 8)
+	value = binary.LittleEndian.AppendUint64(value, extension.TimeReference)
+	value = binary.LittleEndian.AppendUint16(value, extension.Version)
+	value = append(value, extension.UMID[:]...)
+	for _, loudness := range []int16{
+		extension.LoudnessValue,
+		extension.LoudnessRange,
+		extension.MaxTruePeakLevel,
+		extension.MaxMomentaryLoudness,
+		extension.MaxShortTermLoudness,
+	} {
+		value = binary.LittleEndian.AppendUint16(value, uint16(loudness))
+	}
+	value = append(value, make([]byte, 180)...)
+	value = append(value, extension.CodingHistory...)
+
+	w.Chunks = slices.Clone(w.Chunks)
+	index := slices.IndexFunc(w.Chunks, func(chunk Chunk) bool {
+		return chunk.ID == "bext"
+	})
+	if index < 0 {
+		w.Chunks = append([]Chunk{{ID: "bext", Data: value}}, w.Chunks...)
+		return
+	}
+	w.Chunks[index].Data = value
+}
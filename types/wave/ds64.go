@@ -0,0 +1,75 @@
+package wave
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// テーブルを含まないds64チャンクの内容のサイズ
+const ds64Size = 28
+
+// RF64/BW64で4GBを超えるサイズを記録するds64チャンク
+type ds64 struct {
+	riffSize    int64
+	dataSize    int64
+	sampleCount int64
+	// data以外で4GBを超えるチャンクのサイズ
+	table map[string]int64
+	// 読み込んだds64チャンクの内容のサイズ
+	size int64
+}
+
+// RF64/BW64ヘッダーの直後にあるds64チャンクを読み込む
+func readDS64(reader io.ReaderAt, size int64) (ds64, error) {
+	chunkHeader := make([]byte, 8)
+	if _, err := reader.ReadAt(chunkHeader, 12); err != nil || string(chunkHeader[0:4]) != "ds64" {
+		return ds64{}, formatError(12, "RF64/BW64の先頭にds64チャンクがありません")
+	}
+	chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+	if chunkSize < ds64Size {
+		return ds64{}, formatError(16, "ds64チャンクが短すぎます(%dByte)", chunkSize)
+	}
+	if size < 20+chunkSize {
+		return ds64{}, formatError(16, "ds64チャンクのサイズ(%dByte)がファイルの末尾を超えています", chunkSize)
+	}
+	value := make([]byte, chunkSize)
+	if _, err := reader.ReadAt(value, 20); err != nil {
+		return ds64{}, err
+	}
+	// 0-7: RIFFサイズ
+	// 8-15: dataチャンクのサイズ
+	// 16-23: サンプル数
+	// 24-27: テーブルの要素数
+	// 28-: テーブル(チャンクID 4Byte + サイズ 8Byte)
+	result := ds64{
+		riffSize:    int64(binary.LittleEndian.Uint64(value[0:8])),
+		dataSize:    int64(binary.LittleEndian.Uint64(value[8:16])),
+		sampleCount: int64(binary.LittleEndian.Uint64(value[16:24])),
+		table:       map[string]int64{},
+		size:        chunkSize,
+	}
+	tableLength := int(binary.LittleEndian.Uint32(value[24:28]))
+	if int64(ds64Size+tableLength*12) > chunkSize {
+		return ds64{}, formatError(20+24, "ds64チャンクのテーブルの要素数(%d)が不正です", tableLength)
+	}
+	for i := range tableLength {
+		entry := value[ds64Size+i*12:]
+		result.table[string(entry[0:4])] = int64(binary.LittleEndian.Uint64(entry[4:12]))
+	}
+	return result, nil
+}
+
+func (d ds64) chunkSize(id string) (int64, bool) {
+	if id == "data" {
+		return d.dataSize, true
+	}
+	value, ok := d.table[id]
+	return value, ok
+}
+
+func (d ds64) bytes() []byte {
+	value := binary.LittleEndian.AppendUint64(nil, uint64(d.riffSize))
+	value = binary.LittleEndian.AppendUint64(value, uint64(d.dataSize))
+	value = binary.LittleEndian.AppendUint64(value, uint64(d.sampleCount))
+	return binary.LittleEndian.AppendUint32(value, 0)
+}
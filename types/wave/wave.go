@@ -0,0 +1,347 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ryo-kagawa/go-utils/conditional"
+)
+
+// フォーマットタイプ
+const (
+	FormatPCM        = 0x0001
+	FormatIEEEFloat  = 0x0003
+	FormatExtensible = 0xFFFE
+)
+
+// ファイル先頭の識別子
+const (
+	ContainerRIFF = "RIFF"
+	ContainerRF64 = "RF64"
+	// EBU Tech 3285(BWF)の4GBを超える形式
+	ContainerBW64 = "BW64"
+)
+
+// RIFFヘッダー(12Byte) + fmtチャンク(8+16Byte) + dataチャンクのヘッダー(8Byte)
+const CanonicalHeaderSize = 44
+
+// WAVE_FORMAT_EXTENSIBLEのSubFormatのうち、先頭2Byte(フォーマットタイプ)以降の共通部分
+var subFormatSuffix = []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+// WAVEファイルの形式の誤り
+type FormatError struct {
+	// 誤りを検出したファイル先頭からの位置
+	Offset  int64
+	Message string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("WAVEフォーマットエラー(offset %d): %s", e.Offset, e.Message)
+}
+
+func formatError(offset int64, format string, arguments ...any) error {
+	return &FormatError{
+		Offset:  offset,
+		Message: fmt.Sprintf(format, arguments...),
+	}
+}
+
+type Format struct {
+	// WAVE_FORMAT_EXTENSIBLEの場合はSubFormatのフォーマットタイプ
+	FormatTag    uint16
+	Channels     uint16
+	SamplingRate uint32
+	ByteRate     uint32
+	BlockAlign   uint16
+	// 1サンプルあたりの格納ビット数
+	BitDepth uint16
+	// WAVE_FORMAT_EXTENSIBLEとして記録されている
+	Extensible bool
+	// 1サンプルあたりの有効ビット数(WAVE_FORMAT_EXTENSIBLEのみ)
+	ValidBits   uint16
+	ChannelMask uint32
+}
+
+// CDDA(44.1kHz/16bit/ステレオ)の形式
+func CDDA() Format {
+	return NewPCM(2, 44100, 16)
+}
+
+func NewPCM(channels uint16, samplingRate uint32, bitDepth uint16) Format {
+	blockAlign := channels * bitDepth / 8
+	return Format{
+		FormatTag:    FormatPCM,
+		Channels:     channels,
+		SamplingRate: samplingRate,
+		ByteRate:     samplingRate * uint32(blockAlign),
+		BlockAlign:   blockAlign,
+		BitDepth:     bitDepth,
+	}
+}
+
+func parseFormat(value []byte, offset int64) (Format, error) {
+	if len(value) < 16 {
+		return Format{}, formatError(offset, "fmtチャンクが短すぎます(%dByte)", len(value))
+	}
+	format := Format{
+		FormatTag:    binary.LittleEndian.Uint16(value[0:2]),
+		Channels:     binary.LittleEndian.Uint16(value[2:4]),
+		SamplingRate: binary.LittleEndian.Uint32(value[4:8]),
+		ByteRate:     binary.LittleEndian.Uint32(value[8:12]),
+		BlockAlign:   binary.LittleEndian.Uint16(value[12:14]),
+		BitDepth:     binary.LittleEndian.Uint16(value[14:16]),
+	}
+	if format.FormatTag == FormatExtensible {
+		// 16-17: cbSize
+		// 18-19: ValidBitsPerSample
+		// 20-23: ChannelMask
+		// 24-39: SubFormat
+		if len(value) < 40 {
+			return Format{}, formatError(offset, "WAVE_FORMAT_EXTENSIBLEのfmtチャンクが短すぎます(%dByte)", len(value))
+		}
+		if !bytes.Equal(value[26:40], subFormatSuffix) {
+			return Format{}, formatError(offset+24, "WAVE_FORMAT_EXTENSIBLEのSubFormatに未対応です")
+		}
+		format.Extensible = true
+		format.ValidBits = binary.LittleEndian.Uint16(value[18:20])
+		format.ChannelMask = binary.LittleEndian.Uint32(value[20:24])
+		format.FormatTag = binary.LittleEndian.Uint16(value[24:26])
+	}
+	if format.Channels == 0 {
+		return Format{}, formatError(offset+2, "チャンネル数が0です")
+	}
+	if format.BitDepth == 0 || format.BitDepth%8 != 0 {
+		return Format{}, formatError(offset+14, "ビット深度に未対応です(%dbit)", format.BitDepth)
+	}
+	if format.BlockAlign != format.Channels*format.BitDepth/8 {
+		return Format{}, formatError(offset+12, "ブロックアラインメントが一致しません(%d, 期待値%d)", format.BlockAlign, format.Channels*format.BitDepth/8)
+	}
+	if format.ByteRate != format.SamplingRate*uint32(format.BlockAlign) {
+		return Format{}, formatError(offset+8, "バイトレートが一致しません(%d, 期待値%d)", format.ByteRate, format.SamplingRate*uint32(format.BlockAlign))
+	}
+	return format, nil
+}
+
+// fmtチャンクの内容
+func (f Format) bytes() []byte {
+	tag := f.FormatTag
+	if f.Extensible {
+		tag = FormatExtensible
+	}
+	value := binary.LittleEndian.AppendUint16(nil, tag)
+	value = binary.LittleEndian.AppendUint16(value, f.Channels)
+	value = binary.LittleEndian.AppendUint32(value, f.SamplingRate)
+	value = binary.LittleEndian.AppendUint32(value, f.ByteRate)
+	value = binary.LittleEndian.AppendUint16(value, f.BlockAlign)
+	value = binary.LittleEndian.AppendUint16(value, f.BitDepth)
+	if f.Extensible {
+		value = binary.LittleEndian.AppendUint16(value, 22)
+		value = binary.LittleEndian.AppendUint16(value, f.ValidBits)
+		value = binary.LittleEndian.AppendUint32(value, f.ChannelMask)
+		value = binary.LittleEndian.AppendUint16(value, f.FormatTag)
+		value = append(value, subFormatSuffix...)
+	}
+	return value
+}
+
+// fmt・data以外のチャンク
+// NOTE: LIST/bext/JUNK/factなどは内容を解釈せずに保持する
+type Chunk struct {
+	ID   string
+	Data []byte
+	// dataチャンクより後にある
+	AfterData bool
+}
+
+type Wave struct {
+	// RIFF/RF64/BW64
+	// NOTE: RIFFで4GBを超える場合は出力時にRF64とする
+	Container string
+	Format    Format
+	Chunks    []Chunk
+	// dataチャンクの内容の位置とサイズ
+	DataOffset int64
+	DataSize   int64
+}
+
+func New(format Format) Wave {
+	return Wave{
+		Container: ContainerRIFF,
+		Format:    format,
+	}
+}
+
+// RIFFチャンクを順に読み込む
+// NOTE: RIFFチャンクのサイズは誤っているファイルがあるため使用せず、ファイルの末尾まで読み込む
+func Read(reader io.ReaderAt, size int64) (Wave, error) {
+	header := make([]byte, 12)
+	if _, err := reader.ReadAt(header, 0); err != nil {
+		return Wave{}, formatError(0, "RIFFヘッダーがありません")
+	}
+	container := string(header[0:4])
+	switch container {
+	case ContainerRIFF, ContainerRF64, ContainerBW64:
+	default:
+		return Wave{}, formatError(0, "RIFF/RF64/BW64識別子がありません")
+	}
+	if string(header[8:12]) != "WAVE" {
+		return Wave{}, formatError(8, "WAVE識別子がありません")
+	}
+	wave := Wave{
+		Container:  container,
+		DataOffset: -1,
+	}
+	sizes := ds64{}
+	hasFormat := false
+	offset := int64(12)
+	if container != ContainerRIFF {
+		value, err := readDS64(reader, size)
+		if err != nil {
+			return Wave{}, err
+		}
+		sizes = value
+		offset += 8 + sizes.size + sizes.size%2
+	}
+	for offset+8 <= size {
+		chunkHeader := make([]byte, 8)
+		if _, err := reader.ReadAt(chunkHeader, offset); err != nil {
+			return Wave{}, err
+		}
+		id := string(chunkHeader[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		// NOTE: RF64/BW64では4GBを超えるチャンクのサイズをds64チャンクに記録する
+		if container != ContainerRIFF && chunkSize == math.MaxUint32 {
+			value, ok := sizes.chunkSize(id)
+			if !ok {
+				return Wave{}, formatError(offset+4, "%sチャンクのサイズがds64チャンクにありません", id)
+			}
+			chunkSize = value
+		}
+		if size < offset+8+chunkSize {
+			return Wave{}, formatError(offset+4, "%sチャンクのサイズ(%dByte)がファイルの末尾を超えています", id, chunkSize)
+		}
+		switch id {
+		case "fmt ":
+			if hasFormat {
+				return Wave{}, formatError(offset, "fmtチャンクが複数あります")
+			}
+			if 0 <= wave.DataOffset {
+				return Wave{}, formatError(offset, "fmtチャンクがdataチャンクより後にあります")
+			}
+			value := make([]byte, chunkSize)
+			if _, err := reader.ReadAt(value, offset+8); err != nil {
+				return Wave{}, err
+			}
+			format, err := parseFormat(value, offset+8)
+			if err != nil {
+				return Wave{}, err
+			}
+			wave.Format = format
+			hasFormat = true
+		case "data":
+			if 0 <= wave.DataOffset {
+				return Wave{}, formatError(offset, "dataチャンクが複数あります")
+			}
+			if !hasFormat {
+				return Wave{}, formatError(offset, "fmtチャンクがdataチャンクより前にありません")
+			}
+			if chunkSize%int64(wave.Format.BlockAlign) != 0 {
+				return Wave{}, formatError(offset+4, "dataチャンクのサイズ(%dByte)がブロックアラインメントの倍数ではありません", chunkSize)
+			}
+			wave.DataOffset = offset + 8
+			wave.DataSize = chunkSize
+		default:
+			value := make([]byte, chunkSize)
+			if _, err := reader.ReadAt(value, offset+8); err != nil {
+				return Wave{}, err
+			}
+			wave.Chunks = append(
+				wave.Chunks,
+				Chunk{
+					ID:        id,
+					Data:      value,
+					AfterData: 0 <= wave.DataOffset,
+				},
+			)
+		}
+		// NOTE: チャンクは2Byte境界に配置される
+		offset += 8 + chunkSize + chunkSize%2
+	}
+	if !hasFormat {
+		return Wave{}, formatError(offset, "fmtチャンクがありません")
+	}
+	if wave.DataOffset < 0 {
+		return Wave{}, formatError(offset, "dataチャンクがありません")
+	}
+	return wave, nil
+}
+
+// dataチャンクの内容
+func (w Wave) Data(reader io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(reader, w.DataOffset, w.DataSize)
+}
+
+func appendChunk(value []byte, id string, data []byte) []byte {
+	value = append(value, id...)
+	value = binary.LittleEndian.AppendUint32(value, uint32(len(data)))
+	value = append(value, data...)
+	if len(data)%2 != 0 {
+		value = append(value, 0x00)
+	}
+	return value
+}
+
+// dataチャンクの内容の手前までを出力する
+// NOTE: 保持しているチャンクはdataチャンクとの前後関係を維持して出力する
+// NOTE: 4GBを超える場合はRF64とし、サイズをds64チャンクに記録する
+func (w Wave) Header(dataSize int64) []byte {
+	body := appendChunk(nil, "fmt ", w.Format.bytes())
+	for _, chunk := range w.Chunks {
+		if !chunk.AfterData {
+			body = appendChunk(body, chunk.ID, chunk.Data)
+		}
+	}
+	// NOTE: RIFFチャンクのサイズは「WAVE」識別子から末尾まで
+	riffSize := int64(4+len(body)+8) + dataSize + int64(len(w.Trailer(dataSize)))
+	container := w.Container
+	if container == "" || (container == ContainerRIFF && math.MaxUint32 < riffSize) {
+		container = conditional.Value(math.MaxUint32 < riffSize, ContainerRF64, ContainerRIFF)
+	}
+	if container == ContainerRIFF {
+		header := []byte(container)
+		header = binary.LittleEndian.AppendUint32(header, uint32(riffSize))
+		header = append(header, "WAVE"...)
+		header = append(header, body...)
+		header = append(header, "data"...)
+		return binary.LittleEndian.AppendUint32(header, uint32(dataSize))
+	}
+	sizes := ds64{
+		riffSize:    riffSize + 8 + ds64Size,
+		dataSize:    dataSize,
+		sampleCount: dataSize / int64(max(w.Format.BlockAlign, 1)),
+	}
+	header := []byte(container)
+	header = binary.LittleEndian.AppendUint32(header, math.MaxUint32)
+	header = append(header, "WAVE"...)
+	header = appendChunk(header, "ds64", sizes.bytes())
+	header = append(header, body...)
+	header = append(header, "data"...)
+	return binary.LittleEndian.AppendUint32(header, math.MaxUint32)
+}
+
+// dataチャンクの内容より後を出力する
+func (w Wave) Trailer(dataSize int64) []byte {
+	value := []byte{}
+	if dataSize%2 != 0 {
+		value = append(value, 0x00)
+	}
+	for _, chunk := range w.Chunks {
+		if chunk.AfterData {
+			value = appendChunk(value, chunk.ID, chunk.Data)
+		}
+	}
+	return value
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// テキストファイルの文字コード
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	// NOTE: Windowsの拡張文字を含むCP932として読み込む
+	EncodingShiftJIS = "shift-jis"
+	EncodingEUCJP    = "euc-jp"
+	EncodingLatin1   = "latin-1"
+)
+
+var encodings = map[string]encoding.Encoding{
+	EncodingUTF8:     xunicode.UTF8,
+	EncodingUTF16LE:  xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM),
+	EncodingUTF16BE:  xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM),
+	EncodingShiftJIS: japanese.ShiftJIS,
+	EncodingEUCJP:    japanese.EUCJP,
+	EncodingLatin1:   charmap.ISO8859_1,
+}
+
+// BOMの無いUTF-8以外のテキストの文字コードの候補
+// NOTE: 評価が同じ場合は先の候補を優先する
+var candidates = []string{
+	EncodingShiftJIS,
+	EncodingEUCJP,
+	EncodingLatin1,
+}
+
+// 文字コードの指定が正しいかを確認する
+func IsEncoding(name string) bool {
+	_, ok := encodings[name]
+	return ok
+}
+
+// 読み込み時に使用する文字コード
+// NOTE: キーは絶対パス、空の場合は全てのファイル
+var forcedEncodings = map[string]string{}
+
+// 読み込み時に判定せずに使用する文字コードを指定する
+// NOTE: パスが空の場合は全てのファイルに適用する
+func ForceEncoding(filePath string, encodingName string) error {
+	if !IsEncoding(encodingName) {
+		return fmt.Errorf("文字コード%sには対応していません", encodingName)
+	}
+	key := ""
+	if filePath != "" {
+		absolutePath, err := filepath.Abs(filePath)
+		if err != nil {
+			return err
+		}
+		key = absolutePath
+	}
+	forcedEncodings[key] = encodingName
+	return nil
+}
+
+// 指定されたファイルの文字コード
+func forcedEncoding(filePath string) (string, bool) {
+	if absolutePath, err := filepath.Abs(filePath); err == nil {
+		if encodingName, ok := forcedEncodings[absolutePath]; ok {
+			return encodingName, true
+		}
+	}
+	encodingName, ok := forcedEncodings[""]
+	return encodingName, ok
+}
+
+// テキストの文字コードを判定する
+// NOTE: BOMがある場合はBOMに従い、UTF-8として正しい場合はUTF-8とする
+// NOTE: それ以外は各候補で変換した結果を評価し、最も日本語・欧文として自然なものとする
+func DetectEncoding(binary []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(binary, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8, nil
+	// NOTE: EACのログ等はBOM付きのUTF-16で出力される
+	case bytes.HasPrefix(binary, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE, nil
+	case bytes.HasPrefix(binary, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE, nil
+	}
+	if utf8.Valid(binary) {
+		return EncodingUTF8, nil
+	}
+	detected := ""
+	bestScore := 0
+	for _, candidate := range candidates {
+		text, err := encodings[candidate].NewDecoder().Bytes(binary)
+		if err != nil {
+			continue
+		}
+		score, ok := scoreText(string(text))
+		if !ok {
+			continue
+		}
+		if detected == "" || bestScore < score {
+			detected = candidate
+			bestScore = score
+		}
+	}
+	if detected == "" {
+		return "", errors.New("元の文字コードが特定できませんでした")
+	}
+	return detected, nil
+}
+
+// 変換したテキストがどの程度自然かを評価する
+// NOTE: 変換できない文字・制御文字・私用領域の文字を含む場合は候補から外す
+// NOTE: ひらがな・カタカナを最も高く、漢字・全角記号と英字に隣接するアクセント付きの文字を次に高く評価する
+// NOTE: 半角カタカナ・Latin-1の記号・連続するアクセント付きの文字は誤った文字コードで変換した場合に現れやすいため低く評価する
+func scoreText(text string) (int, bool) {
+	runes := []rune(text)
+	isASCIILetter := func(i int) bool {
+		return 0 <= i && i < len(runes) && runes[i] < utf8.RuneSelf && unicode.IsLetter(runes[i])
+	}
+	score := 0
+	for i, r := range runes {
+		switch {
+		case r == utf8.RuneError,
+			unicode.Is(unicode.Co, r),
+			unicode.IsControl(r) && r != '\t' && r != '\r' && r != '\n':
+			return 0, false
+		case 0x3040 <= r && r <= 0x30FF:
+			score += 2
+		case unicode.Is(unicode.Han, r),
+			0x3000 <= r && r <= 0x303F,
+			0xFF01 <= r && r <= 0xFF5E:
+			score++
+		case 0xFF61 <= r && r <= 0xFF9F,
+			0xA0 <= r && r <= 0xBF:
+			score--
+		case 0xC0 <= r && r <= 0xFF:
+			if isASCIILetter(i-1) || isASCIILetter(i+1) {
+				score++
+			} else {
+				score--
+			}
+		}
+	}
+	return score, true
+}
+
+// UTF-8のテキストを指定した文字コードに変換する
+// NOTE: UTF-16はBOMを付与しないため、必要な場合は「\uFEFF」を変換して先頭に付与する
+func EncodeText(text string, encodingName string) (string, error) {
+	var e encoding.Encoding
+	switch encodingName {
+	case EncodingUTF16LE:
+		e = xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM)
+	case EncodingUTF16BE:
+		e = xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM)
+	default:
+		var ok bool
+		e, ok = encodings[encodingName]
+		if !ok {
+			return "", fmt.Errorf("文字コード%sには対応していません", encodingName)
+		}
+	}
+	return e.NewEncoder().String(text)
+}
+
+// 指定した文字コードでUTF-8に変換する
+// NOTE: UTF-16のBOMは取り除き、UTF-8のBOMはそのまま残す
+func DecodeText(binary []byte, encodingName string) (string, error) {
+	e, ok := encodings[encodingName]
+	if !ok {
+		return "", fmt.Errorf("文字コード%sには対応していません", encodingName)
+	}
+	text, err := e.NewDecoder().Bytes(binary)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
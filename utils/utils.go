@@ -1,47 +1,38 @@
 package utils
 
 import (
-	"bytes"
-	"errors"
-	"io"
 	"iter"
 	"os"
 	"strings"
-	"unicode/utf8"
 
 	"github.com/ryo-kagawa/go-utils/conditional"
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/japanese"
-	"golang.org/x/text/transform"
 )
 
-var encoders = []encoding.Encoding{
-	japanese.ShiftJIS,
-	japanese.EUCJP,
+// テキストファイルを読み込みUTF-8に変換する
+// NOTE: ForceEncodingで文字コードが指定されている場合は判定せずにその文字コードとする
+func ReadTextFileToUTF8(filePath string) (string, error) {
+	text, _, err := ReadTextFile(filePath)
+	return text, err
 }
 
-func ReadTextFileToUTF8(filePath string) (string, error) {
+// テキストファイルをUTF-8に変換して読み込み、元の文字コードと合わせて返す
+func ReadTextFile(filePath string) (string, string, error) {
 	binary, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", err
-	}
-	if utf8.Valid(binary) {
-		return string(binary), nil
+		return "", "", err
 	}
-	for _, encoder := range encoders {
-		reader := transform.NewReader(
-			bytes.NewReader(binary),
-			encoder.NewDecoder(),
-		)
-		newBinary, err := io.ReadAll(reader)
+	encodingName, ok := forcedEncoding(filePath)
+	if !ok {
+		encodingName, err = DetectEncoding(binary)
 		if err != nil {
-			return "", err
-		}
-		if utf8.Valid(newBinary) {
-			return string(newBinary), nil
+			return "", "", err
 		}
 	}
-	return "", errors.New("元の文字コードが特定できませんでした")
+	text, err := DecodeText(binary, encodingName)
+	if err != nil {
+		return "", "", err
+	}
+	return text, encodingName, nil
 }
 
 func SplitNewLineWithoutEmpty(value string) iter.Seq[string] {
@@ -59,10 +50,15 @@ func SplitNewLineWithoutEmpty(value string) iter.Seq[string] {
 	}
 }
 
+// NOTE: 空文字や「"」1文字の場合はそのまま返す
 func TrimQuotesIfWrapped(value string) string {
-	return conditional.Value(
-		value[0] == '"' && value[len(value)-1] == '"',
-		value[1:len(value)-1],
-		value,
+	return conditional.Func(
+		2 <= len(value) && value[0] == '"' && value[len(value)-1] == '"',
+		func() string {
+			return value[1 : len(value)-1]
+		},
+		func() string {
+			return value
+		},
 	)
 }